@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"myproject/config"
+	"myproject/databases"
+	"myproject/middlewares"
+	"myproject/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+
+	tests := []struct {
+		name   string
+		body   gin.H
+		status int
+	}{
+		{"valid", gin.H{"username": "usr1", "password": testPassword}, http.StatusOK},
+		{"taken username", gin.H{"username": "usr1", "password": testPassword}, http.StatusConflict},
+		{"short username", gin.H{"username": "u", "password": testPassword}, http.StatusBadRequest},
+		{"weak password", gin.H{"username": "usr2", "password": "password"}, http.StatusBadRequest},
+		{"invalid email", gin.H{"username": "usr3", "password": testPassword, "email": "nobody"}, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		if recorder := api.request(http.MethodPost, "/register", "", test.body); recorder.Code != test.status {
+			t.Fatalf("%s: status = %d, want %d, body: %s", test.name, recorder.Code, test.status, recorder.Body.String())
+		}
+	}
+
+	tokens := api.login("usr1")
+	me := decode[models.User](t, api.expect(http.StatusOK, http.MethodGet, "/me", tokens.Token, nil))
+	if me.Username != "usr1" || me.Role != models.RoleUser {
+		t.Fatalf("me = %+v", me)
+	}
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/login", "", gin.H{"username": "usr1", "password": "Wrong2024x"})
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/login", "", gin.H{"username": "nobody", "password": testPassword})
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/me", "garbage", nil)
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+	first := api.login("usr1")
+
+	second := decode[middlewares.TokenPair](t, api.expect(http.StatusOK, http.MethodPost, "/token/refresh", "", gin.H{"refresh_token": first.RefreshToken}))
+	if second.RefreshToken == first.RefreshToken || second.Token == "" {
+		t.Fatal("refresh did not rotate tokens")
+	}
+	api.expect(http.StatusOK, http.MethodGet, "/me", second.Token, nil)
+
+	// Повторное использование токена обновления отзывает всю сессию, в том числе выданные в ней токены
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/token/refresh", "", gin.H{"refresh_token": first.RefreshToken})
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/token/refresh", "", gin.H{"refresh_token": second.RefreshToken})
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/me", second.Token, nil)
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/me", first.Token, nil)
+
+	// Остальные сессии пользователя продолжают действовать
+	other := api.login("usr1")
+	api.expect(http.StatusOK, http.MethodGet, "/me", other.Token, nil)
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/token/refresh", "", gin.H{"refresh_token": "garbage"})
+
+	// Выход завершает только текущую сессию
+	third := api.login("usr1")
+	api.expect(http.StatusOK, http.MethodPost, "/logout", third.Token, nil)
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/me", third.Token, nil)
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/token/refresh", "", gin.H{"refresh_token": third.RefreshToken})
+	api.expect(http.StatusOK, http.MethodGet, "/me", other.Token, nil)
+
+	// Отключённый аккаунт не может обновить токены
+	user, _ := api.stores.Users.GetUserByUsername(context.Background(), "usr1")
+	if err := api.stores.Users.SetUserDisabled(context.Background(), user.ID, true); err != nil {
+		t.Fatalf("SetUserDisabled: %v", err)
+	}
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/token/refresh", "", gin.H{"refresh_token": other.RefreshToken})
+}
+
+func TestLoginLockout(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), func(cfg *config.Config) {
+		cfg.RateLimit.Enabled = false
+	})
+	api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+	wrong := gin.H{"username": "usr1", "password": "Wrong2024x"}
+
+	for i := 1; i < 5; i++ {
+		api.expect(http.StatusUnauthorized, http.MethodPost, "/login", "", wrong)
+	}
+	// Пятая неудачная попытка блокирует вход, в том числе с верным паролем
+	recorder := api.expect(http.StatusTooManyRequests, http.MethodPost, "/login", "", wrong)
+	if recorder.Header().Get("Retry-After") == "" {
+		t.Fatal("locked login response has no Retry-After")
+	}
+	api.expect(http.StatusTooManyRequests, http.MethodPost, "/login", "", gin.H{"username": "usr1", "password": testPassword})
+
+	// Блокировка относится только к одному имени пользователя
+	api.createUser("usr2", models.RoleUser, primitive.NilObjectID)
+	api.login("usr2")
+}
+
+func TestRateLimits(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), func(cfg *config.Config) {
+		cfg.Lockout.Threshold = 0
+		cfg.RateLimit.LoginPerUsername = config.Rate{Requests: 2, Period: time.Hour}
+	})
+	wrong := gin.H{"username": "usr1", "password": "Wrong2024x"}
+
+	tests := []struct {
+		name    string
+		path    string
+		bodies  []gin.H
+		allowed int
+	}{
+		{"login per username", "/login", []gin.H{wrong, wrong, wrong}, 2},
+		{"register per address", "/register", []gin.H{
+			{"username": "usr1", "password": testPassword},
+			{"username": "usr2", "password": testPassword},
+			{"username": "usr3", "password": testPassword},
+			{"username": "usr4", "password": testPassword},
+			{"username": "usr5", "password": testPassword},
+			{"username": "usr6", "password": testPassword},
+		}, 5},
+		// Варианты написания одного адреса расходуют общий лимит
+		{"account email per address", "/password/reset", []gin.H{
+			{"email": "usr1@example.com"},
+			{"email": "USR1@example.com"},
+			{"email": "usr1@EXAMPLE.COM"},
+			{"email": "Usr1@Example.com"},
+		}, 3},
+	}
+	for _, test := range tests {
+		for i, body := range test.bodies {
+			recorder := api.request(http.MethodPost, test.path, "", body)
+			if limited := recorder.Code == http.StatusTooManyRequests; limited != (i >= test.allowed) {
+				t.Fatalf("%s: request %d: status = %d, body: %s", test.name, i+1, recorder.Code, recorder.Body.String())
+			}
+			if i >= test.allowed && recorder.Header().Get("Retry-After") == "" {
+				t.Fatalf("%s: limited response has no Retry-After", test.name)
+			}
+		}
+	}
+}
+
+func TestEmailVerification(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), func(cfg *config.Config) {
+		cfg.Account.RequireVerifiedEmail = true
+	})
+
+	api.expect(http.StatusBadRequest, http.MethodPost, "/register", "", gin.H{"username": "usr1", "password": testPassword})
+	api.expect(http.StatusOK, http.MethodPost, "/register", "", gin.H{"username": "usr1", "password": testPassword, "email": "usr1@example.com"})
+	api.expect(http.StatusForbidden, http.MethodPost, "/login", "", gin.H{"username": "usr1", "password": testPassword})
+
+	// Новое письмо отменяет ссылку из предыдущего
+	first := api.mailer.lastToken(t)
+	api.expect(http.StatusAccepted, http.MethodPost, "/email/verification", "", gin.H{"email": "usr1@example.com"})
+	token := api.mailer.lastToken(t)
+	if token == first {
+		t.Fatal("verification email was not sent again")
+	}
+	api.expect(http.StatusBadRequest, http.MethodPost, "/email/verification/confirm", "", gin.H{"token": first})
+
+	// Токен подтверждения почты не подходит для сброса пароля
+	api.expect(http.StatusBadRequest, http.MethodPost, "/password/reset/confirm", "", gin.H{"token": token, "password": "Otter2024x"})
+
+	api.expect(http.StatusOK, http.MethodPost, "/email/verification/confirm", "", gin.H{"token": token})
+	api.expect(http.StatusBadRequest, http.MethodPost, "/email/verification/confirm", "", gin.H{"token": token})
+	api.login("usr1")
+
+	// На подтверждённый адрес письмо подтверждения больше не отправляется
+	sent := api.mailer.count()
+	api.expect(http.StatusAccepted, http.MethodPost, "/email/verification", "", gin.H{"email": "usr1@example.com"})
+	if api.mailer.count() != sent {
+		t.Fatal("verification email sent to verified address")
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	user := api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+	session := api.login("usr1")
+
+	// Письмо отправляется только на подтверждённый адрес, а ответ не раскрывает, зарегистрирован ли адрес
+	if err := api.stores.Users.SetEmail(context.Background(), user.ID, "usr1@example.com"); err != nil {
+		t.Fatalf("SetEmail: %v", err)
+	}
+	api.expect(http.StatusAccepted, http.MethodPost, "/password/reset", "", gin.H{"email": "usr1@example.com"})
+	api.expect(http.StatusAccepted, http.MethodPost, "/password/reset", "", gin.H{"email": "nobody@example.com"})
+	if api.mailer.count() != 0 {
+		t.Fatal("password reset email sent to unverified address")
+	}
+	if err := api.stores.Users.SetEmailVerified(context.Background(), user.ID, "usr1@example.com"); err != nil {
+		t.Fatalf("SetEmailVerified: %v", err)
+	}
+	api.expect(http.StatusAccepted, http.MethodPost, "/password/reset", "", gin.H{"email": "usr1@example.com"})
+	token := api.mailer.lastToken(t)
+
+	// Неподходящий пароль не расходует токен
+	api.expect(http.StatusBadRequest, http.MethodPost, "/password/reset/confirm", "", gin.H{"token": token, "password": "password"})
+	api.expect(http.StatusOK, http.MethodPost, "/password/reset/confirm", "", gin.H{"token": token, "password": "Otter2024x"})
+	api.expect(http.StatusBadRequest, http.MethodPost, "/password/reset/confirm", "", gin.H{"token": token, "password": "Eagle2024x"})
+	api.expect(http.StatusBadRequest, http.MethodPost, "/password/reset/confirm", "", gin.H{"token": "garbage", "password": "Eagle2024x"})
+
+	// Сброс пароля завершает все сессии
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/token/refresh", "", gin.H{"refresh_token": session.RefreshToken})
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/login", "", gin.H{"username": "usr1", "password": testPassword})
+	api.expect(http.StatusOK, http.MethodPost, "/login", "", gin.H{"username": "usr1", "password": "Otter2024x"})
+}
+
+func TestProfile(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+	token := api.login("usr1").Token
+
+	me := decode[models.User](t, api.expect(http.StatusOK, http.MethodPatch, "/me", token, gin.H{"display_name": "User One", "email": "usr1@example.com"}))
+	if me.Profile.DisplayName != "User One" || me.Email != "usr1@example.com" || me.EmailVerified {
+		t.Fatalf("me after update = %+v", me)
+	}
+	if api.mailer.count() != 1 {
+		t.Fatal("verification email was not sent to the new address")
+	}
+	api.expect(http.StatusBadRequest, http.MethodPatch, "/me", token, gin.H{"housing": "castle"})
+
+	// Смена пароля завершает прежние сессии и открывает новую
+	api.expect(http.StatusBadRequest, http.MethodPut, "/me/password", token, gin.H{"current_password": "Wrong2024x", "new_password": "Otter2024x"})
+	tokens := decode[middlewares.TokenPair](t, api.expect(http.StatusOK, http.MethodPut, "/me/password", token, gin.H{"current_password": testPassword, "new_password": "Otter2024x"}))
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/me", token, nil)
+	api.expect(http.StatusOK, http.MethodGet, "/me", tokens.Token, nil)
+
+	// Удалённый аккаунт обезличивается, и его имя освобождается
+	api.expect(http.StatusBadRequest, http.MethodDelete, "/me", tokens.Token, gin.H{"password": testPassword})
+	api.expect(http.StatusOK, http.MethodDelete, "/me", tokens.Token, gin.H{"password": "Otter2024x"})
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/me", tokens.Token, nil)
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/login", "", gin.H{"username": "usr1", "password": "Otter2024x"})
+	api.expect(http.StatusOK, http.MethodPost, "/register", "", gin.H{"username": "usr1", "password": testPassword})
+}
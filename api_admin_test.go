@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"myproject/databases"
+	"myproject/handlers"
+	"myproject/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAssignRole(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	ctx := context.Background()
+	admin := api.createAdmin()
+	shelter := api.createShelter("Home")
+
+	// Помощник может управлять пользователями, но не имеет остальных прав администратора
+	api.expect(http.StatusOK, http.MethodPut, "/admin/roles/helper", admin, gin.H{"permissions": []string{models.PermUsersManage}})
+	api.createUser("helper", "helper", primitive.NilObjectID)
+	helper := api.login("helper").Token
+
+	api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+	api.createUser("usr2", models.RoleUser, primitive.NilObjectID)
+	api.createUser("moder", models.RoleModerator, primitive.NilObjectID)
+	session := api.login("moder")
+	deleted := api.createUser("gone", models.RoleUser, primitive.NilObjectID)
+	if err := api.stores.Users.AnonymizeUser(ctx, deleted.ID, time.Now()); err != nil {
+		t.Fatalf("AnonymizeUser: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		username string
+		body     gin.H
+		status   int
+	}{
+		{"helper grants admin", helper, "usr1", gin.H{"role": models.RoleAdmin}, http.StatusForbidden},
+		{"helper grants moderator", helper, "usr1", gin.H{"role": models.RoleModerator}, http.StatusForbidden},
+		{"helper demotes admin", helper, "admin", gin.H{"role": models.RoleUser}, http.StatusForbidden},
+		{"helper demotes moderator", helper, "moder", gin.H{"role": models.RoleUser}, http.StatusForbidden},
+		{"helper grants own role", helper, "usr1", gin.H{"role": "helper"}, http.StatusOK},
+		{"user without permission", api.login("usr2").Token, "moder", gin.H{"role": models.RoleUser}, http.StatusForbidden},
+		{"unknown role", admin, "moder", gin.H{"role": "owner"}, http.StatusBadRequest},
+		{"shelter for other role", admin, "moder", gin.H{"role": models.RoleUser, "shelter_id": shelter.ID.Hex()}, http.StatusBadRequest},
+		{"staff without shelter", admin, "moder", gin.H{"role": models.RoleShelterStaff}, http.StatusBadRequest},
+		{"staff of missing shelter", admin, "moder", gin.H{"role": models.RoleShelterStaff, "shelter_id": primitive.NewObjectID().Hex()}, http.StatusBadRequest},
+		{"deleted user", admin, "deleted-" + deleted.ID.Hex(), gin.H{"role": models.RoleModerator}, http.StatusNotFound},
+		{"missing user", admin, "nobody", gin.H{"role": models.RoleModerator}, http.StatusNotFound},
+		{"admin assigns staff", admin, "moder", gin.H{"role": models.RoleShelterStaff, "shelter_id": shelter.ID.Hex()}, http.StatusOK},
+	}
+	for _, test := range tests {
+		path := "/admin/users/" + test.username + "/role"
+		if recorder := api.request(http.MethodPut, path, test.token, test.body); recorder.Code != test.status {
+			t.Fatalf("%s: status = %d, want %d, body: %s", test.name, recorder.Code, test.status, recorder.Body.String())
+		}
+	}
+
+	user, _ := api.stores.Users.GetUserByUsername(ctx, "moder")
+	if user.Role != models.RoleShelterStaff || user.ShelterID != shelter.ID {
+		t.Fatalf("moder: role = %s, shelter = %s", user.Role, user.ShelterID.Hex())
+	}
+	// Токены с прежней ролью больше не действуют
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/me", session.Token, nil)
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/token/refresh", "", gin.H{"refresh_token": session.RefreshToken})
+	entries, err := api.stores.Audit.FindAudit(ctx, databases.AuditFilter{Action: "user.role"}, 10)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("audit entries = %v, %v, want 2", entries, err)
+	}
+}
+
+func TestShelterStaff(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	ctx := context.Background()
+	admin := api.createAdmin()
+	home := api.createShelter("Home")
+	other := api.createShelter("Other")
+
+	api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+	session := api.login("usr1")
+	api.createUser("moder", models.RoleModerator, primitive.NilObjectID)
+	disabled := api.createUser("usr2", models.RoleUser, primitive.NilObjectID)
+	if err := api.stores.Users.SetUserDisabled(ctx, disabled.ID, true); err != nil {
+		t.Fatalf("SetUserDisabled: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		status   int
+	}{
+		{"user", "usr1", http.StatusOK},
+		{"already staff", "usr1", http.StatusConflict},
+		{"other role", "moder", http.StatusConflict},
+		{"disabled account", "usr2", http.StatusConflict},
+		{"missing user", "nobody", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		path := "/admin/shelters/" + home.ID.Hex() + "/staff"
+		if recorder := api.request(http.MethodPost, path, admin, gin.H{"username": test.username}); recorder.Code != test.status {
+			t.Fatalf("%s: status = %d, want %d, body: %s", test.name, recorder.Code, test.status, recorder.Body.String())
+		}
+	}
+
+	// Сессия, открытая до назначения, завершается
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/me", session.Token, nil)
+
+	// Сотрудник управляет только животными своего приюта и добавляет животных только в него
+	staff := api.login("usr1").Token
+	own := api.createPet(home.ID, "Rex", models.PetAvailable)
+	foreign := api.createPet(other.ID, "Tom", models.PetAvailable)
+	api.expect(http.StatusOK, http.MethodPost, "/admin/pets/"+own.ID.Hex()+"/status", staff, gin.H{"status": models.PetOnHold, "reason": "vet visit"})
+	api.expect(http.StatusForbidden, http.MethodPost, "/admin/pets/"+foreign.ID.Hex()+"/status", staff, gin.H{"status": models.PetOnHold, "reason": "vet visit"})
+	api.expect(http.StatusForbidden, http.MethodDelete, "/admin/pets/"+foreign.ID.Hex(), staff, nil)
+	api.expect(http.StatusOK, http.MethodPost, "/admin/pets", staff, gin.H{"name": "Bim", "species": "dog", "shelter_id": other.ID.Hex()})
+	page, err := api.stores.Pets.FindPets(ctx, databases.PetFilter{ShelterID: home.ID}, databases.PageRequest{})
+	if err != nil || page.Total != 2 {
+		t.Fatalf("pets of home shelter = %v, %v, want 2", page, err)
+	}
+	api.expect(http.StatusForbidden, http.MethodPost, "/admin/shelters", staff, gin.H{"name": "Mine"})
+
+	api.expect(http.StatusNotFound, http.MethodDelete, "/admin/shelters/"+other.ID.Hex()+"/staff/usr1", admin, nil)
+	api.expect(http.StatusOK, http.MethodDelete, "/admin/shelters/"+home.ID.Hex()+"/staff/usr1", admin, nil)
+	if user, _ := api.stores.Users.GetUserByUsername(ctx, "usr1"); user.Role != models.RoleUser || !user.ShelterID.IsZero() {
+		t.Fatalf("usr1 after removal: role = %s, shelter = %s", user.Role, user.ShelterID.Hex())
+	}
+	// Бывший сотрудник больше не может изменять животных приюта с прежним токеном
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/admin/pets/"+own.ID.Hex()+"/status", staff, gin.H{"status": models.PetAvailable, "reason": "recovered"})
+}
+
+func TestUserAdministration(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	admin := api.createAdmin()
+	api.expect(http.StatusOK, http.MethodPut, "/admin/roles/helper", admin, gin.H{"permissions": []string{models.PermUsersManage}})
+	api.createUser("helper", "helper", primitive.NilObjectID)
+	helper := api.login("helper").Token
+	api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+	session := api.login("usr1")
+
+	list := decode[handlers.UserListResponse](t, api.expect(http.StatusOK, http.MethodGet, "/admin/users?q=usr&sort=username", helper, nil))
+	if list.Total != 1 || list.Items[0].Username != "usr1" {
+		t.Fatalf("users = %+v", list)
+	}
+	api.expect(http.StatusBadRequest, http.MethodGet, "/admin/users?disabled=maybe", helper, nil)
+
+	tests := []struct {
+		name   string
+		token  string
+		path   string
+		status int
+	}{
+		{"disable own account", helper, "/admin/users/helper/disable", http.StatusBadRequest},
+		{"disable admin", helper, "/admin/users/admin/disable", http.StatusForbidden},
+		{"disable user", helper, "/admin/users/usr1/disable", http.StatusOK},
+		{"missing user", helper, "/admin/users/nobody/disable", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		if recorder := api.request(http.MethodPost, test.path, test.token, nil); recorder.Code != test.status {
+			t.Fatalf("%s: status = %d, want %d, body: %s", test.name, recorder.Code, test.status, recorder.Body.String())
+		}
+	}
+
+	// Отключение завершает сессии и запрещает вход, пока аккаунт не включён снова
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/me", session.Token, nil)
+	api.expect(http.StatusForbidden, http.MethodPost, "/login", "", gin.H{"username": "usr1", "password": testPassword})
+	api.expect(http.StatusOK, http.MethodPost, "/admin/users/usr1/enable", helper, nil)
+	api.login("usr1")
+}
+
+func TestRoles(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	admin := api.createAdmin()
+
+	role := decode[models.Role](t, api.expect(http.StatusOK, http.MethodPut, "/admin/roles/editor", admin,
+		gin.H{"description": "Editor", "permissions": []string{models.PermPetsUpdate, models.PermPetsUpdate}}))
+	if len(role.Permissions) != 1 || role.BuiltIn {
+		t.Fatalf("role = %+v", role)
+	}
+	if roles := decode[[]models.Role](t, api.expect(http.StatusOK, http.MethodGet, "/admin/roles", admin, nil)); len(roles) != len(models.DefaultRoles())+1 {
+		t.Fatalf("roles = %+v", roles)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   gin.H
+		status int
+	}{
+		{"invalid name", http.MethodPut, "/admin/roles/Editor!", gin.H{}, http.StatusBadRequest},
+		{"unknown permission", http.MethodPut, "/admin/roles/editor", gin.H{"permissions": []string{"pets:eat"}}, http.StatusBadRequest},
+		{"admin without roles permission", http.MethodPut, "/admin/roles/admin", gin.H{"permissions": []string{models.PermUsersManage}}, http.StatusBadRequest},
+		{"delete built-in role", http.MethodDelete, "/admin/roles/" + models.RoleModerator, nil, http.StatusConflict},
+		{"delete role", http.MethodDelete, "/admin/roles/editor", nil, http.StatusOK},
+		{"delete missing role", http.MethodDelete, "/admin/roles/editor", nil, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		if recorder := api.request(test.method, test.path, admin, test.body); recorder.Code != test.status {
+			t.Fatalf("%s: status = %d, want %d, body: %s", test.name, recorder.Code, test.status, recorder.Body.String())
+		}
+	}
+}
+
+func TestAudit(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	admin := api.createAdmin()
+	api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+	api.expect(http.StatusOK, http.MethodPut, "/admin/roles/editor", admin, gin.H{"permissions": []string{models.PermPetsUpdate}})
+	api.expect(http.StatusOK, http.MethodPost, "/admin/users/usr1/disable", admin, nil)
+
+	// Значения изменений выдаются как JSON, а не как строки с JSON
+	type auditEntry struct {
+		Action    string `json:"action"`
+		ActorRole string `json:"actor_role"`
+		Changes   map[string]struct {
+			Before json.RawMessage `json:"before"`
+			After  json.RawMessage `json:"after"`
+		} `json:"changes"`
+	}
+	entries := decode[[]auditEntry](t, api.expect(http.StatusOK, http.MethodGet, "/admin/audit?entity=user", admin, nil))
+	if len(entries) != 1 || entries[0].Action != "user.disable" || entries[0].ActorRole != models.RoleAdmin {
+		t.Fatalf("audit = %+v", entries)
+	}
+	if change := entries[0].Changes["disabled"]; string(change.Before) != "false" || string(change.After) != "true" {
+		t.Fatalf("audit changes = %+v", entries[0].Changes)
+	}
+	api.expect(http.StatusBadRequest, http.MethodGet, "/admin/audit?from=yesterday", admin, nil)
+	api.createUser("usr2", models.RoleUser, primitive.NilObjectID)
+	api.expect(http.StatusForbidden, http.MethodGet, "/admin/audit", api.login("usr2").Token, nil)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"myproject/databases"
+	"myproject/models"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestApplicationReview(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	admin := api.createAdmin()
+	home := api.createShelter("Home")
+	pet := api.createPet(home.ID, "Rex", models.PetAvailable)
+	api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+	api.createUser("usr2", models.RoleUser, primitive.NilObjectID)
+	applicant := api.login("usr1").Token
+	competitor := api.login("usr2").Token
+	// Сотрудник приюта, которому выдано право рассматривать заявки, рассматривает только заявки на животных своего приюта
+	api.expect(http.StatusOK, http.MethodPut, "/admin/roles/"+models.RoleShelterStaff, admin,
+		gin.H{"permissions": []string{models.PermPetsCreate, models.PermPetsUpdate, models.PermPetsDelete, models.PermApplicationsReview}})
+	api.createUser("staff1", models.RoleShelterStaff, home.ID)
+	api.createUser("staff2", models.RoleShelterStaff, api.createShelter("Other").ID)
+	staff := api.login("staff1").Token
+	foreignStaff := api.login("staff2").Token
+
+	application := decode[models.Application](t, api.expect(http.StatusCreated, http.MethodPost, "/applications", applicant, gin.H{"pet_id": pet.ID.Hex(), "message": "I have a yard"}))
+	api.expect(http.StatusConflict, http.MethodPost, "/applications", applicant, gin.H{"pet_id": pet.ID.Hex()})
+	api.expect(http.StatusNotFound, http.MethodPost, "/applications", applicant, gin.H{"pet_id": primitive.NewObjectID().Hex()})
+	other := decode[models.Application](t, api.expect(http.StatusCreated, http.MethodPost, "/applications", competitor, gin.H{"pet_id": pet.ID.Hex()}))
+	path := "/admin/applications/" + application.ID.Hex() + "/status"
+
+	// Шаги выполняются по порядку над одной заявкой
+	tests := []struct {
+		name   string
+		token  string
+		status string
+		want   int
+	}{
+		{"approve submitted", admin, models.ApplicationApproved, http.StatusConflict},
+		{"withdraw by reviewer", admin, models.ApplicationWithdrawn, http.StatusBadRequest},
+		{"unknown status", admin, "accepted", http.StatusBadRequest},
+		{"staff of other shelter", foreignStaff, models.ApplicationUnderReview, http.StatusForbidden},
+		{"review", staff, models.ApplicationUnderReview, http.StatusOK},
+		{"review again", admin, models.ApplicationUnderReview, http.StatusConflict},
+		{"approve by staff of other shelter", foreignStaff, models.ApplicationApproved, http.StatusForbidden},
+		{"approve", staff, models.ApplicationApproved, http.StatusOK},
+		{"reject approved", admin, models.ApplicationRejected, http.StatusConflict},
+	}
+	for _, test := range tests {
+		if recorder := api.request(http.MethodPut, path, test.token, gin.H{"status": test.status}); recorder.Code != test.want {
+			t.Fatalf("%s: status = %d, want %d, body: %s", test.name, recorder.Code, test.want, recorder.Body.String())
+		}
+	}
+
+	// Одобрение усыновляет животное и отклоняет остальные заявки на него
+	ctx := context.Background()
+	if got, _ := api.stores.Pets.GetPet(ctx, pet.ID); got.Status != models.PetAdopted {
+		t.Fatalf("pet status = %s, want adopted", got.Status)
+	}
+	if got, _ := api.stores.Applications.GetApplication(ctx, other.ID); got.Status != models.ApplicationRejected {
+		t.Fatalf("competing application status = %s, want rejected", got.Status)
+	}
+	mine := decode[[]models.Application](t, api.expect(http.StatusOK, http.MethodGet, "/applications", applicant, nil))
+	if len(mine) != 1 || mine[0].Status != models.ApplicationApproved {
+		t.Fatalf("my applications = %+v", mine)
+	}
+	api.expect(http.StatusConflict, http.MethodPost, "/applications/"+application.ID.Hex()+"/withdraw", applicant, nil)
+	api.expect(http.StatusConflict, http.MethodPost, "/applications", competitor, gin.H{"pet_id": pet.ID.Hex()})
+	api.expect(http.StatusForbidden, http.MethodPut, path, applicant, gin.H{"status": models.ApplicationRejected})
+}
+
+func TestApplicationWithdraw(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	pet := api.createPet(api.createShelter("Home").ID, "Rex", models.PetAvailable)
+	api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+	api.createUser("usr2", models.RoleUser, primitive.NilObjectID)
+	applicant := api.login("usr1").Token
+
+	application := decode[models.Application](t, api.expect(http.StatusCreated, http.MethodPost, "/applications", applicant, gin.H{"pet_id": pet.ID.Hex()}))
+	path := "/applications/" + application.ID.Hex() + "/withdraw"
+
+	api.expect(http.StatusNotFound, http.MethodPost, path, api.login("usr2").Token, nil)
+	api.expect(http.StatusOK, http.MethodPost, path, applicant, nil)
+	api.expect(http.StatusConflict, http.MethodPost, path, applicant, nil)
+	// После отзыва можно подать новую заявку
+	api.expect(http.StatusCreated, http.MethodPost, "/applications", applicant, gin.H{"pet_id": pet.ID.Hex()})
+}
+
+// failingPetStore - хранилище животных, которое не может изменить статус животного
+type failingPetStore struct {
+	databases.PetStore
+	err error
+}
+
+func (store *failingPetStore) SetPetStatus(ctx context.Context, change *models.PetStatusChange) error {
+	if change.To == models.PetAdopted {
+		return store.err
+	}
+	return store.PetStore.SetPetStatus(ctx, change)
+}
+
+// failingApplicationStore - хранилище заявок, которое не может закрыть заявки на животное
+type failingApplicationStore struct {
+	databases.ApplicationStore
+}
+
+func (store *failingApplicationStore) CloseApplicationsForPet(ctx context.Context, petID, except primitive.ObjectID, comment string) error {
+	return errors.New("database is unavailable")
+}
+
+func TestApproveRollback(t *testing.T) {
+	tests := []struct {
+		name   string
+		fail   func(stores *databases.Stores)
+		status int
+	}{
+		{"pet status changed", func(stores *databases.Stores) {
+			stores.Pets = &failingPetStore{PetStore: stores.Pets, err: databases.ErrConflict}
+		}, http.StatusConflict},
+		{"pet store failed", func(stores *databases.Stores) {
+			stores.Pets = &failingPetStore{PetStore: stores.Pets, err: errors.New("database is unavailable")}
+		}, http.StatusInternalServerError},
+		{"closing applications failed", func(stores *databases.Stores) {
+			stores.Applications = &failingApplicationStore{ApplicationStore: stores.Applications}
+		}, http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		stores := databases.CreateMemoryStores()
+		test.fail(stores)
+		api := createTestAPI(t, stores, nil)
+		ctx := context.Background()
+		admin := api.createAdmin()
+		pet := api.createPet(api.createShelter("Home").ID, "Rex", models.PetAvailable)
+		api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+
+		application := decode[models.Application](t, api.expect(http.StatusCreated, http.MethodPost, "/applications", api.login("usr1").Token, gin.H{"pet_id": pet.ID.Hex()}))
+		path := "/admin/applications/" + application.ID.Hex() + "/status"
+		api.expect(http.StatusOK, http.MethodPut, path, admin, gin.H{"status": models.ApplicationUnderReview})
+
+		if recorder := api.request(http.MethodPut, path, admin, gin.H{"status": models.ApplicationApproved}); recorder.Code != test.status {
+			t.Fatalf("%s: status = %d, want %d, body: %s", test.name, recorder.Code, test.status, recorder.Body.String())
+		}
+
+		// Неудачное одобрение не оставляет частичных изменений
+		if got, _ := api.stores.Applications.GetApplication(ctx, application.ID); got.Status != models.ApplicationUnderReview {
+			t.Fatalf("%s: application status = %s, want under_review", test.name, got.Status)
+		}
+		if got, _ := api.stores.Pets.GetPet(ctx, pet.ID); got.Status != models.PetAvailable {
+			t.Fatalf("%s: pet status = %s, want available", test.name, got.Status)
+		}
+		entries, err := api.stores.Audit.FindAudit(ctx, databases.AuditFilter{Action: "pet.status"}, 10)
+		if err != nil || len(entries) != 0 {
+			t.Fatalf("%s: pet status audit entries = %v, %v, want none", test.name, entries, err)
+		}
+	}
+}
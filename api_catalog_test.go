@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"myproject/databases"
+	"myproject/handlers"
+	"myproject/models"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPetCRUD(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	admin := api.createAdmin()
+	shelter := api.createShelter("Home")
+
+	// Поля корзины из тела запроса не учитываются: новое животное сразу видно в списке
+	api.expect(http.StatusOK, http.MethodPost, "/admin/pets", admin, gin.H{"name": "Rex", "species": "dog", "age": 2, "shelter_id": shelter.ID.Hex(),
+		"deleted_at": "2026-01-01T00:00:00Z", "deleted_by": primitive.NewObjectID().Hex()})
+	list := decode[handlers.PetListResponse](t, api.expect(http.StatusOK, http.MethodGet, "/pets", "", nil))
+	if list.Total != 1 || list.Items[0].Name != "Rex" || list.Items[0].Status != models.PetAvailable {
+		t.Fatalf("pets = %+v", list)
+	}
+	id := list.Items[0].ID.Hex()
+
+	api.expect(http.StatusOK, http.MethodPut, "/admin/pets/"+id, admin, gin.H{"name": "Max", "species": "dog", "age": 3, "shelter_id": shelter.ID.Hex()})
+	pet := decode[models.Pet](t, api.expect(http.StatusOK, http.MethodGet, "/pets/"+id, "", nil))
+	if pet.Name != "Max" || pet.Age != 3 {
+		t.Fatalf("pet after update = %+v", pet)
+	}
+
+	api.expect(http.StatusOK, http.MethodDelete, "/admin/pets/"+id, admin, nil)
+	api.expect(http.StatusNotFound, http.MethodGet, "/pets/"+id, "", nil)
+	api.expect(http.StatusOK, http.MethodPost, "/admin/pets/"+id+"/restore", admin, nil)
+	api.expect(http.StatusOK, http.MethodGet, "/pets/"+id, "", nil)
+
+	tests := []struct {
+		name   string
+		token  string
+		body   gin.H
+		status int
+	}{
+		{"anonymous", "", gin.H{"name": "Bim", "species": "dog", "shelter_id": shelter.ID.Hex()}, http.StatusUnauthorized},
+		{"unknown species", admin, gin.H{"name": "Bim", "species": "dragon", "shelter_id": shelter.ID.Hex()}, http.StatusBadRequest},
+		{"initial status adopted", admin, gin.H{"name": "Bim", "species": "dog", "status": models.PetAdopted, "shelter_id": shelter.ID.Hex()}, http.StatusBadRequest},
+		{"missing shelter", admin, gin.H{"name": "Bim", "species": "dog", "shelter_id": primitive.NewObjectID().Hex()}, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		if recorder := api.request(http.MethodPost, "/admin/pets", test.token, test.body); recorder.Code != test.status {
+			t.Fatalf("%s: status = %d, want %d, body: %s", test.name, recorder.Code, test.status, recorder.Body.String())
+		}
+	}
+}
+
+// racingPetStore - хранилище животных, в котором сразу после чтения животного параллельный запрос добавляет ему фотографию
+type racingPetStore struct {
+	databases.PetStore
+	photo *models.Photo
+}
+
+func (store *racingPetStore) GetPet(ctx context.Context, id primitive.ObjectID) (*models.Pet, error) {
+	pet, err := store.PetStore.GetPet(ctx, id)
+	if err == nil && store.photo != nil {
+		err = store.PetStore.AddPetPhoto(ctx, id, store.photo)
+		store.photo = nil
+	}
+	return pet, err
+}
+
+func TestPhotoConflict(t *testing.T) {
+	stores := databases.CreateMemoryStores()
+	racing := &racingPetStore{PetStore: stores.Pets}
+	stores.Pets = racing
+	api := createTestAPI(t, stores, nil)
+	admin := api.createAdmin()
+	pet := api.createPet(api.createShelter("Home").ID, "Rex", models.PetAvailable)
+	cover := models.Photo{ID: primitive.NewObjectID(), Key: "cover.jpg", Cover: true}
+	if err := stores.Pets.AddPetPhoto(context.Background(), pet.ID, &cover); err != nil {
+		t.Fatalf("AddPetPhoto: %v", err)
+	}
+	path := "/admin/pets/" + pet.ID.Hex() + "/photos/"
+
+	// Изменение по устаревшему списку фотографий отклоняется и не теряет загруженную параллельно фотографию
+	uploaded := models.Photo{ID: primitive.NewObjectID(), Key: "uploaded.jpg"}
+	racing.photo = &uploaded
+	api.expect(http.StatusConflict, http.MethodDelete, path+cover.ID.Hex(), admin, nil)
+	got := decode[models.Pet](t, api.expect(http.StatusOK, http.MethodGet, "/pets/"+pet.ID.Hex(), "", nil))
+	if len(got.Photos) != 2 || got.Photos[1].ID != uploaded.ID {
+		t.Fatalf("photos after conflict = %+v", got.Photos)
+	}
+
+	api.expect(http.StatusOK, http.MethodPut, path+uploaded.ID.Hex()+"/cover", admin, nil)
+	api.expect(http.StatusOK, http.MethodPut, path+"order", admin, gin.H{"photo_ids": []string{uploaded.ID.Hex(), cover.ID.Hex()}})
+	api.expect(http.StatusOK, http.MethodDelete, path+cover.ID.Hex(), admin, nil)
+	got = decode[models.Pet](t, api.expect(http.StatusOK, http.MethodGet, "/pets/"+pet.ID.Hex(), "", nil))
+	if len(got.Photos) != 1 || got.Photos[0].ID != uploaded.ID || !got.Photos[0].Cover {
+		t.Fatalf("photos = %+v", got.Photos)
+	}
+}
+
+func TestPetStatusTransitions(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	admin := api.createAdmin()
+	pet := api.createPet(api.createShelter("Home").ID, "Rex", models.PetIntake)
+	path := "/admin/pets/" + pet.ID.Hex() + "/status"
+
+	// Шаги выполняются по порядку над одним животным
+	tests := []struct {
+		to     string
+		reason string
+		status int
+	}{
+		{models.PetAdopted, "skip checks", http.StatusConflict},
+		{models.PetAvailable, "", http.StatusBadRequest},
+		{models.PetAvailable, "vaccinated", http.StatusOK},
+		{models.PetOnHold, "vet visit", http.StatusOK},
+		{models.PetAdopted, "adopted", http.StatusConflict},
+		{models.PetAvailable, "recovered", http.StatusOK},
+		{models.PetAdopted, "adopted", http.StatusOK},
+		{models.PetAvailable, "returned", http.StatusConflict},
+		{models.PetReturned, "returned", http.StatusOK},
+		{"lost", "unknown status", http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		recorder := api.request(http.MethodPost, path, admin, gin.H{"status": test.to, "reason": test.reason})
+		if recorder.Code != test.status {
+			t.Fatalf("transition to %s: status = %d, want %d, body: %s", test.to, recorder.Code, test.status, recorder.Body.String())
+		}
+	}
+
+	history := decode[[]models.PetStatusChange](t, api.expect(http.StatusOK, http.MethodGet, "/admin/pets/"+pet.ID.Hex()+"/history", admin, nil))
+	var statuses []string
+	for _, change := range history {
+		statuses = append(statuses, change.From+">"+change.To)
+	}
+	if want := "intake>available available>on_hold on_hold>available available>adopted adopted>returned"; strings.Join(statuses, " ") != want {
+		t.Fatalf("history = %v, want %s", statuses, want)
+	}
+}
+
+func TestPetPagination(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	shelterID := api.createShelter("Home").ID
+	for _, name := range []string{"Bim", "Rex", "Ace", "Tom", "Max"} {
+		api.createPet(shelterID, name, models.PetAvailable)
+	}
+
+	pages := []struct {
+		query string
+		want  string
+		links []string
+	}{
+		{"?sort=name&limit=2", "[Ace Bim]", []string{`rel="first"`, `rel="last"`}},
+		{"?sort=name&limit=2&page=3", "[Tom]", []string{`rel="prev"`}},
+		{"?sort=-name&limit=2&page=2", "[Max Bim]", []string{`rel="prev"`}},
+		{"?sort=name&limit=2&page=9", "[]", []string{`page=3`}},
+	}
+	for _, test := range pages {
+		recorder := api.expect(http.StatusOK, http.MethodGet, "/pets"+test.query, "", nil)
+		list := decode[handlers.PetListResponse](t, recorder)
+		if got := fmt.Sprint(petNames(list.Items)); got != test.want || list.Total != 5 {
+			t.Fatalf("%s: pets = %s (total %d), want %s", test.query, got, list.Total, test.want)
+		}
+		for _, link := range test.links {
+			if !strings.Contains(recorder.Header().Get("Link"), link) {
+				t.Fatalf("%s: Link %q does not contain %s", test.query, recorder.Header().Get("Link"), link)
+			}
+		}
+	}
+
+	// Обход всех страниц по курсору
+	var names []string
+	query := "?sort=name&limit=2"
+	for {
+		list := decode[handlers.PetListResponse](t, api.expect(http.StatusOK, http.MethodGet, "/pets"+query, "", nil))
+		names = append(names, petNames(list.Items)...)
+		if list.NextCursor == "" {
+			break
+		}
+		query = "?sort=name&limit=2&cursor=" + list.NextCursor
+	}
+	if got := fmt.Sprint(names); got != "[Ace Bim Max Rex Tom]" {
+		t.Fatalf("pets = %s", got)
+	}
+
+	// Неверные параметры, в том числе номер страницы, смещение которой переполнило бы int
+	for _, query := range []string{
+		"?page=0",
+		"?page=-1",
+		"?page=abc",
+		"?page=9223372036854775807",
+		"?limit=100&page=92233720368547760",
+		"?limit=0",
+		"?limit=101",
+		"?sort=weight",
+		"?cursor=garbage",
+	} {
+		api.expect(http.StatusBadRequest, http.MethodGet, "/pets"+query, "", nil)
+	}
+}
+
+func TestShelterCRUD(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	admin := api.createAdmin()
+
+	shelter := decode[models.Shelter](t, api.expect(http.StatusCreated, http.MethodPost, "/admin/shelters", admin, gin.H{"name": "Home", "address": "Main st 1"}))
+	path := "/admin/shelters/" + shelter.ID.Hex()
+
+	api.expect(http.StatusOK, http.MethodPut, path, admin, gin.H{"name": "Shelter", "address": "Main st 2"})
+	got := decode[models.Shelter](t, api.expect(http.StatusOK, http.MethodGet, "/shelters/"+shelter.ID.Hex(), "", nil))
+	if got.Name != "Shelter" || got.Address != "Main st 2" {
+		t.Fatalf("shelter after update = %+v", got)
+	}
+	if list := decode[[]models.Shelter](t, api.expect(http.StatusOK, http.MethodGet, "/shelters", "", nil)); len(list) != 1 {
+		t.Fatalf("shelters = %+v", list)
+	}
+
+	// Приют с животными удалить нельзя
+	pet := api.createPet(shelter.ID, "Rex", models.PetAvailable)
+	api.expect(http.StatusConflict, http.MethodDelete, path, admin, nil)
+	if err := api.stores.Pets.DeletePet(context.Background(), pet.ID, primitive.NewObjectID()); err != nil {
+		t.Fatalf("DeletePet: %v", err)
+	}
+	api.expect(http.StatusOK, http.MethodDelete, path, admin, nil)
+	api.expect(http.StatusNotFound, http.MethodGet, "/shelters/"+shelter.ID.Hex(), "", nil)
+}
+
+func TestFavorites(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+	token := api.login("usr1").Token
+	shelterID := api.createShelter("Home").ID
+	rex := api.createPet(shelterID, "Rex", models.PetAvailable)
+	tom := api.createPet(shelterID, "Tom", models.PetAvailable)
+
+	api.expect(http.StatusOK, http.MethodPut, "/favorites/"+rex.ID.Hex(), token, nil)
+	api.expect(http.StatusOK, http.MethodPut, "/favorites/"+tom.ID.Hex(), token, nil)
+	api.expect(http.StatusNotFound, http.MethodPut, "/favorites/"+primitive.NewObjectID().Hex(), token, nil)
+
+	favorites := decode[[]models.Pet](t, api.expect(http.StatusOK, http.MethodGet, "/favorites", token, nil))
+	if got := fmt.Sprint(petNames(favorites)); got != "[Tom Rex]" {
+		t.Fatalf("favorites = %s, want [Tom Rex]", got)
+	}
+
+	// Животное в корзине пропадает из избранного
+	if err := api.stores.Pets.DeletePet(context.Background(), tom.ID, primitive.NewObjectID()); err != nil {
+		t.Fatalf("DeletePet: %v", err)
+	}
+	favorites = decode[[]models.Pet](t, api.expect(http.StatusOK, http.MethodGet, "/favorites", token, nil))
+	if got := fmt.Sprint(petNames(favorites)); got != "[Rex]" {
+		t.Fatalf("favorites = %s, want [Rex]", got)
+	}
+
+	api.expect(http.StatusOK, http.MethodDelete, "/favorites/"+rex.ID.Hex(), token, nil)
+	api.expect(http.StatusNotFound, http.MethodDelete, "/favorites/"+rex.ID.Hex(), token, nil)
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/favorites", "", nil)
+}
+
+func TestSavedSearches(t *testing.T) {
+	api := createTestAPI(t, databases.CreateMemoryStores(), nil)
+	api.createUser("usr1", models.RoleUser, primitive.NilObjectID)
+	api.createUser("usr2", models.RoleUser, primitive.NilObjectID)
+	token := api.login("usr1").Token
+	other := api.login("usr2").Token
+
+	search := decode[models.SavedSearch](t, api.expect(http.StatusCreated, http.MethodPost, "/searches", token, gin.H{"name": "Dogs", "query": "?species=dog"}))
+	api.expect(http.StatusBadRequest, http.MethodPost, "/searches", token, gin.H{"name": "Bad", "query": "age_min=abc"})
+
+	if searches := decode[[]models.SavedSearch](t, api.expect(http.StatusOK, http.MethodGet, "/searches", token, nil)); len(searches) != 1 || searches[0].Name != "Dogs" {
+		t.Fatalf("searches = %+v", searches)
+	}
+	// Чужой поиск удалить нельзя
+	api.expect(http.StatusNotFound, http.MethodDelete, "/searches/"+search.ID.Hex(), other, nil)
+	api.expect(http.StatusOK, http.MethodDelete, "/searches/"+search.ID.Hex(), token, nil)
+	if searches := decode[[]models.SavedSearch](t, api.expect(http.StatusOK, http.MethodGet, "/searches", token, nil)); len(searches) != 0 {
+		t.Fatalf("searches after delete = %+v", searches)
+	}
+}
+
+// petNames возвращает имена животных pets
+func petNames(pets []models.Pet) []string {
+	names := []string{}
+	for _, pet := range pets {
+		names = append(names, pet.Name)
+	}
+	return names
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"myproject/apierror"
+	"myproject/config"
+	"myproject/databases"
+	"myproject/media"
+	"myproject/middlewares"
+	"myproject/models"
+	"myproject/notifications"
+	"myproject/validation"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testPassword - пароль, который получают все пользователи, созданные testAPI.createUser
+const testPassword = "Zebra2024x"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	// Ошибки, которые обработчики только пишут в лог, проверяются по состоянию хранилищ
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := validation.Register(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// testAPI - маршруты первой версии API поверх хранилищ в памяти
+type testAPI struct {
+	t      *testing.T
+	router *gin.Engine
+	stores *databases.Stores
+	mailer *testMailer
+}
+
+// createTestAPI создаёт API поверх stores с конфигурацией по умолчанию.
+// configure, если задан, изменяет конфигурацию до создания обработчиков
+func createTestAPI(t *testing.T, stores *databases.Stores, configure func(cfg *config.Config)) *testAPI {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.JWT.Secret = "test-secret"
+	cfg.Password.BcryptCost = bcrypt.MinCost
+	cfg.Account.VerifyURL = "https://example.test/verify"
+	cfg.Account.ResetURL = "https://example.test/reset"
+	if configure != nil {
+		configure(cfg)
+	}
+
+	if err := databases.SeedDefaultRoles(context.Background(), stores.Roles); err != nil {
+		t.Fatalf("SeedDefaultRoles: %v", err)
+	}
+	blobStore, err := media.CreateLocalBlobStore(t.TempDir(), cfg.Media.BaseURL)
+	if err != nil {
+		t.Fatalf("CreateLocalBlobStore: %v", err)
+	}
+	logger := slog.Default()
+	matcher := notifications.CreateMatcher(stores.Searches, stores.Pets, stores.Users, notifications.CreateInboxNotifier(stores.Notifications), logger)
+	mailer := &testMailer{}
+
+	router := gin.New()
+	router.Use(apierror.Handler(), apierror.Recovery())
+	mountAPI(router, createAPIHandlers(cfg, stores, matcher, blobStore, mailer), []apiVersion{{Prefix: "/v1", Register: registerV1}})
+
+	return &testAPI{t: t, router: router, stores: stores, mailer: mailer}
+}
+
+// request выполняет запрос к API. body, если задан, передаётся в формате JSON, token - в заголовке Authorization
+func (api *testAPI) request(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	api.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			api.t.Fatalf("marshal request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	request := httptest.NewRequest(method, "/v1"+path, reader)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	recorder := httptest.NewRecorder()
+	api.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// expect выполняет запрос и проверяет статус ответа
+func (api *testAPI) expect(status int, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	api.t.Helper()
+
+	recorder := api.request(method, path, token, body)
+	if recorder.Code != status {
+		api.t.Fatalf("%s %s: status = %d, want %d, body: %s", method, path, recorder.Code, status, recorder.Body.String())
+	}
+	return recorder
+}
+
+// createUser добавляет в хранилище пользователя с ролью role и паролем testPassword
+func (api *testAPI) createUser(username, role string, shelterID primitive.ObjectID) *models.User {
+	api.t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		api.t.Fatalf("GenerateFromPassword: %v", err)
+	}
+	user := &models.User{Username: username, Password: string(hash), Role: role, ShelterID: shelterID, CreatedAt: time.Now()}
+	if err := api.stores.Users.CreateUser(context.Background(), user); err != nil {
+		api.t.Fatalf("CreateUser: %v", err)
+	}
+	return user
+}
+
+// login входит под именем username с паролем testPassword и возвращает выданные токены
+func (api *testAPI) login(username string) middlewares.TokenPair {
+	api.t.Helper()
+
+	recorder := api.expect(http.StatusOK, http.MethodPost, "/login", "", gin.H{"username": username, "password": testPassword})
+	return decode[middlewares.TokenPair](api.t, recorder)
+}
+
+// createAdmin добавляет администратора и возвращает его токен доступа
+func (api *testAPI) createAdmin() string {
+	api.t.Helper()
+
+	api.createUser("admin", models.RoleAdmin, primitive.NilObjectID)
+	return api.login("admin").Token
+}
+
+// createShelter добавляет в хранилище приют name
+func (api *testAPI) createShelter(name string) *models.Shelter {
+	api.t.Helper()
+
+	shelter := &models.Shelter{Name: name}
+	if err := api.stores.Shelters.CreateShelter(context.Background(), shelter); err != nil {
+		api.t.Fatalf("CreateShelter: %v", err)
+	}
+	return shelter
+}
+
+// createPet добавляет в хранилище собаку name приюта shelterID в статусе status
+func (api *testAPI) createPet(shelterID primitive.ObjectID, name, status string) *models.Pet {
+	api.t.Helper()
+
+	pet := &models.Pet{ShelterID: shelterID, Name: name, Species: "dog", Status: status, CreatedAt: time.Now()}
+	if err := api.stores.Pets.CreatePet(context.Background(), pet); err != nil {
+		api.t.Fatalf("CreatePet: %v", err)
+	}
+	return pet
+}
+
+// decode разбирает JSON-ответ recorder
+func decode[T any](t *testing.T, recorder *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var value T
+	if err := json.Unmarshal(recorder.Body.Bytes(), &value); err != nil {
+		t.Fatalf("decode response %s: %v", recorder.Body.String(), err)
+	}
+	return value
+}
+
+// testMailer запоминает отправленные письма
+type testMailer struct {
+	mutex    sync.Mutex
+	messages []testMail
+}
+
+type testMail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+func (mailer *testMailer) Send(to, subject, body string) error {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	mailer.messages = append(mailer.messages, testMail{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+// count возвращает число отправленных писем
+func (mailer *testMailer) count() int {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	return len(mailer.messages)
+}
+
+// accountTokenPattern находит токен в ссылке из письма
+var accountTokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+// lastToken возвращает токен из ссылки последнего отправленного письма
+func (mailer *testMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	if len(mailer.messages) == 0 {
+		t.Fatal("no email sent")
+	}
+	match := accountTokenPattern.FindStringSubmatch(mailer.messages[len(mailer.messages)-1].Body)
+	if match == nil {
+		t.Fatal("email does not contain a token")
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatalf("unescape token: %v", err)
+	}
+	return token
+}
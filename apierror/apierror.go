@@ -0,0 +1,73 @@
+package apierror
+
+import (
+	"fmt"
+	"myproject/validation"
+	"net/http"
+	"time"
+)
+
+// Error - ошибка API, которую middleware Handler преобразует в ответ application/problem+json.
+// Обработчики передают её в gin через c.Error и не формируют ответ сами
+type Error struct {
+	Status int
+	// Detail - описание ошибки для клиента
+	Detail string
+	// Fields - ошибки проверки отдельных полей тела запроса
+	Fields []validation.FieldError
+	// Cause - исходная ошибка. Записывается в лог и не передаётся клиенту
+	Cause error
+	// RetryAfter - через сколько клиент может повторить запрос (заголовок Retry-After). Ноль - заголовок не отправляется
+	RetryAfter time.Duration
+}
+
+func (err *Error) Error() string {
+	if err.Cause != nil {
+		return fmt.Sprintf("%d %s: %v", err.Status, err.Detail, err.Cause)
+	}
+	return fmt.Sprintf("%d %s", err.Status, err.Detail)
+}
+
+func (err *Error) Unwrap() error {
+	return err.Cause
+}
+
+// New создаёт ошибку с произвольным HTTP-статусом
+func New(status int, detail string) *Error {
+	return &Error{Status: status, Detail: detail}
+}
+
+// Validation - запрос содержит неверные данные. fields перечисляет неверные поля тела запроса, если они известны
+func Validation(detail string, fields ...validation.FieldError) *Error {
+	return &Error{Status: http.StatusBadRequest, Detail: detail, Fields: fields}
+}
+
+// Unauthorized - пользователь не аутентифицирован или его токен недействителен
+func Unauthorized(detail string) *Error {
+	return New(http.StatusUnauthorized, detail)
+}
+
+// Forbidden - у пользователя нет прав на действие
+func Forbidden(detail string) *Error {
+	return New(http.StatusForbidden, detail)
+}
+
+// NotFound - запрошенный объект не существует
+func NotFound(detail string) *Error {
+	return New(http.StatusNotFound, detail)
+}
+
+// Conflict - действие противоречит текущему состоянию объекта
+func Conflict(detail string) *Error {
+	return New(http.StatusConflict, detail)
+}
+
+// TooManyRequests - клиент превысил допустимое число запросов и может повторить запрос через retryAfter
+func TooManyRequests(detail string, retryAfter time.Duration) *Error {
+	return &Error{Status: http.StatusTooManyRequests, Detail: detail, RetryAfter: retryAfter}
+}
+
+// Internal - внутренняя ошибка сервера. cause записывается в лог, клиент получает только detail
+func Internal(detail string, cause error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Detail: detail, Cause: cause}
+}
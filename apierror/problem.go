@@ -0,0 +1,94 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"myproject/logging"
+	"myproject/validation"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType - тип содержимого ответа с ошибкой по RFC 7807
+const ContentType = "application/problem+json"
+
+// Problem - тело ответа с ошибкой в формате RFC 7807
+type Problem struct {
+	Type     string `json:"type" example:"about:blank"`
+	Title    string `json:"title" example:"Not Found"`
+	Status   int    `json:"status" example:"404"`
+	Detail   string `json:"detail" example:"Pet not found"`
+	Instance string `json:"instance" example:"/pets/66b1f0c2a4e5d7f8a9b0c1d2"`
+	// RequestID совпадает с заголовком X-Request-ID ответа
+	RequestID string `json:"request_id"`
+	// Errors - ошибки проверки отдельных полей тела запроса
+	Errors []validation.FieldError `json:"errors,omitempty"`
+}
+
+// Handler преобразует ошибки, переданные обработчиками через c.Error, в ответ application/problem+json.
+// Ошибки, не являющиеся *Error, считаются внутренними
+func Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		var apiErr *Error
+		if err := c.Errors.Last().Err; !errors.As(err, &apiErr) {
+			apiErr = Internal("Internal server error", err)
+		}
+		Write(c, apiErr)
+	}
+}
+
+// Recovery перехватывает панику обработчика, записывает её в лог вместе со стеком вызовов
+// и отвечает внутренней ошибкой в том же формате
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logging.FromContext(c.Request.Context()).Error("panic recovered",
+					"panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+				Write(c, Internal("Internal server error", nil))
+				c.Abort()
+			}
+		}()
+		c.Next()
+	}
+}
+
+// Abort прерывает обработку запроса с ошибкой err. Используется в middleware
+func Abort(c *gin.Context, err *Error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// Write отправляет ошибку err клиенту
+func Write(c *gin.Context, err *Error) {
+	if err.Status >= http.StatusInternalServerError {
+		logging.FromContext(c.Request.Context()).Error("request failed", "detail", err.Detail, "error", err.Cause)
+	}
+
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(err.Status),
+		Status:    err.Status,
+		Detail:    err.Detail,
+		Instance:  c.Request.URL.Path,
+		RequestID: c.GetString("requestID"),
+		Errors:    err.Fields,
+	}
+	if err.RetryAfter > 0 {
+		// Заголовок содержит целое число секунд, поэтому время округляется вверх
+		c.Header("Retry-After", strconv.FormatInt(int64((err.RetryAfter+time.Second-1)/time.Second), 10))
+	}
+	// gin не перезаписывает уже установленный Content-Type
+	c.Header("Content-Type", ContentType)
+	c.JSON(err.Status, problem)
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"myproject/databases"
+	"myproject/logging"
+	"myproject/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Logger записывает в журнал аудита изменения, выполненные через административные маршруты
+type Logger struct {
+	store databases.AuditStore
+}
+
+func CreateLogger(store databases.AuditStore) *Logger {
+	return &Logger{store: store}
+}
+
+// Record записывает действие текущего пользователя над сущностью entity. before и after - состояние сущности
+// до и после изменения (nil, если сущности не было), в журнал попадают только отличающиеся поля.
+// Изменение к этому моменту уже выполнено, поэтому ошибка записи не прерывает запрос и только пишется в лог
+func (logger *Logger) Record(c *gin.Context, action, entity, entityID string, before, after interface{}) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to compute audit changes", "action", action, "entity_id", entityID, "error", err)
+		return
+	}
+
+	// Пользователь без прав получил бы отказ раньше, поэтому ID всегда установлен middlewares.Authenticate
+	actorID, _ := primitive.ObjectIDFromHex(c.GetString("userID"))
+	entry := models.AuditEntry{
+		ActorID:   actorID,
+		ActorRole: c.GetString("role"),
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		Changes:   changes,
+		IP:        c.ClientIP(),
+		Timestamp: time.Now(),
+	}
+	// Запись не отменяется, если клиент разорвал соединение после выполненного изменения
+	if err := logger.store.RecordAudit(context.WithoutCancel(c.Request.Context()), &entry); err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to record audit entry", "action", action, "entity_id", entityID, "error", err)
+	}
+}
+
+// Diff сравнивает JSON-представления before и after и возвращает отличающиеся поля верхнего уровня.
+// Поле, которое есть только с одной стороны и имеет пустое значение, изменением не считается
+func Diff(before, after interface{}) (map[string]models.AuditChange, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]models.AuditChange{}
+	for name, value := range beforeFields {
+		afterValue, ok := afterFields[name]
+		if !ok && isEmptyJSON(value) {
+			continue
+		}
+		if !bytes.Equal(value, afterValue) {
+			changes[name] = models.AuditChange{Before: string(value), After: string(afterValue)}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok && !isEmptyJSON(value) {
+			changes[name] = models.AuditChange{After: string(value)}
+		}
+	}
+
+	return changes, nil
+}
+
+// jsonFields - вспомогательная функция, возвращающая поля JSON-представления value
+func jsonFields(value interface{}) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if value == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// isEmptyJSON - вспомогательная функция, проверяющая, является ли значение JSON пустым
+func isEmptyJSON(value json.RawMessage) bool {
+	switch string(value) {
+	case "null", `""`, "0", "false", "[]", "{}", `"000000000000000000000000"`:
+		return true
+	}
+	return false
+}
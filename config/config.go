@@ -0,0 +1,496 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Secret - строка с секретным значением. При выводе в лог или JSON значение скрывается
+type Secret string
+
+func (secret Secret) String() string {
+	if secret == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+func (secret Secret) GoString() string {
+	return secret.String()
+}
+
+func (secret Secret) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(secret.String())), nil
+}
+
+// Value возвращает настоящее значение секрета
+func (secret Secret) Value() string {
+	return string(secret)
+}
+
+// ServerConfig - настройки HTTP-сервера
+type ServerConfig struct {
+	Address string `yaml:"address"`
+	// ReadTimeout и WriteTimeout ограничивают чтение запроса (вместе с телом) и запись ответа
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	// IdleTimeout - время ожидания следующего запроса в открытом соединении
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout - время на завершение выполняющихся запросов при остановке сервера
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// LegacyRoutes - обслуживать маршруты /v1 также без префикса версии, как устаревшие
+	LegacyRoutes bool `yaml:"legacy_routes"`
+	// LegacySunset - дата отключения маршрутов без префикса версии для заголовка Sunset
+	LegacySunset time.Time `yaml:"legacy_sunset"`
+	// TrustedProxies - адреса и подсети обратных прокси, которым разрешено передавать адрес клиента
+	// в X-Forwarded-For. Без них адресом клиента считается адрес соединения
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// MongoConfig - настройки подключения к MongoDB
+type MongoConfig struct {
+	URI      Secret `yaml:"uri"`
+	Database string `yaml:"database"`
+}
+
+// JWTConfig - настройки токенов доступа и обновления
+type JWTConfig struct {
+	Secret     Secret        `yaml:"secret"`
+	AccessTTL  time.Duration `yaml:"access_ttl"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl"`
+}
+
+// PasswordConfig - настройки хранения паролей пользователей
+type PasswordConfig struct {
+	// BcryptCost - сложность хеширования bcrypt. Каждая единица вдвое замедляет и хеширование, и подбор
+	BcryptCost int `yaml:"bcrypt_cost"`
+}
+
+// AccountConfig - настройки подтверждения почты и восстановления пароля
+type AccountConfig struct {
+	// VerificationTTL и ResetTTL - время действия ссылок подтверждения почты и сброса пароля
+	VerificationTTL time.Duration `yaml:"verification_ttl"`
+	ResetTTL        time.Duration `yaml:"reset_ttl"`
+	// VerifyURL и ResetURL - адреса страниц клиента, на которые ведут ссылки из писем (к ним добавляется ?token=...).
+	// Если адрес не задан, письмо содержит только токен
+	VerifyURL string `yaml:"verify_url"`
+	ResetURL  string `yaml:"reset_url"`
+	// RequireVerifiedEmail - запрещать вход, пока пользователь не подтвердил адрес почты
+	RequireVerifiedEmail bool `yaml:"require_verified_email"`
+	// MailDir - каталог, в который сохраняются письма, если SMTP не настроен. Если не задан, письма записываются в лог
+	MailDir string `yaml:"mail_dir"`
+}
+
+// MediaConfig - настройки хранения фотографий
+type MediaConfig struct {
+	Dir     string `yaml:"dir"`
+	BaseURL string `yaml:"base_url"`
+	// MaxPhotoSize - максимальный размер загружаемой фотографии в байтах
+	MaxPhotoSize int64 `yaml:"max_photo_size"`
+	// ThumbnailSize - максимальная сторона миниатюры в пикселях
+	ThumbnailSize int `yaml:"thumbnail_size"`
+	// MaxPhotoDimension и MaxPhotoPixels - максимальная сторона и площадь фотографии в пикселях.
+	// Проверяются по заголовку изображения до его декодирования: небольшой файл может объявлять
+	// огромные размеры, и декодирование заняло бы гигабайты памяти
+	MaxPhotoDimension int `yaml:"max_photo_dimension"`
+	MaxPhotoPixels    int `yaml:"max_photo_pixels"`
+}
+
+// SMTPConfig - настройки отправки уведомлений по почте. Если Host не задан, почтовые уведомления не отправляются
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password Secret `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// NotificationsConfig - настройки уведомлений о новых животных по сохранённым поискам
+type NotificationsConfig struct {
+	// DigestInterval - период отправки сводок для поисков в режиме daily
+	DigestInterval time.Duration `yaml:"digest_interval"`
+	SMTP           SMTPConfig    `yaml:"smtp"`
+}
+
+// TrashConfig - настройки корзины удалённых домашних животных
+type TrashConfig struct {
+	// Retention - срок хранения животного в корзине, после которого оно удаляется окончательно
+	Retention time.Duration `yaml:"retention"`
+	// PurgeInterval - период проверки корзины
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+// Rate - ограничение частоты запросов: не более Requests запросов подряд, после чего
+// возможность сделать запрос восстанавливается равномерно, Requests раз за Period
+type Rate struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+}
+
+// RateLimitConfig - ограничения частоты запросов к входу и регистрации
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Backend - где хранится состояние ограничений: memory (в памяти процесса)
+	Backend          string `yaml:"backend"`
+	LoginPerIP       Rate   `yaml:"login_per_ip"`
+	LoginPerUsername Rate   `yaml:"login_per_username"`
+	RegisterPerIP    Rate   `yaml:"register_per_ip"`
+	// AccountEmailPerIP ограничивает запросы писем подтверждения почты и сброса пароля
+	AccountEmailPerIP Rate `yaml:"account_email_per_ip"`
+	// AccountEmailPerAddress ограничивает те же запросы для одного адреса почты
+	AccountEmailPerAddress Rate `yaml:"account_email_per_address"`
+}
+
+// LockoutConfig - временная блокировка входа после неудачных попыток подряд
+type LockoutConfig struct {
+	// Threshold - число неудачных попыток до первой блокировки. 0 отключает блокировку
+	Threshold int `yaml:"threshold"`
+	// Duration - длительность первой блокировки. Каждая следующая неудачная попытка удваивает её, но не больше MaxDuration
+	Duration    time.Duration `yaml:"duration"`
+	MaxDuration time.Duration `yaml:"max_duration"`
+	// ResetAfter - через сколько после последней неудачной попытки счётчик сбрасывается
+	ResetAfter time.Duration `yaml:"reset_after"`
+}
+
+// LogConfig - настройки журнала приложения
+type LogConfig struct {
+	// Level - минимальный уровень записей: debug, info, warn или error
+	Level string `yaml:"level"`
+	// Format - формат записей: json или text
+	Format string `yaml:"format"`
+}
+
+// TracingConfig - настройки трассировки запросов OpenTelemetry
+type TracingConfig struct {
+	// Exporter - куда отправляются спаны: none (трассировка выключена), stdout или otlp
+	Exporter string `yaml:"exporter"`
+	// Endpoint - адрес коллектора OTLP/HTTP, например http://localhost:4318. Если не задан,
+	// используется переменная OTEL_EXPORTER_OTLP_ENDPOINT или адрес по умолчанию
+	Endpoint    string `yaml:"endpoint"`
+	ServiceName string `yaml:"service_name"`
+	// SampleRatio - доля записываемых трассировок от 0 до 1 для запросов без заголовка traceparent
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// Config - конфигурация приложения
+type Config struct {
+	Server  ServerConfig  `yaml:"server"`
+	Log     LogConfig     `yaml:"log"`
+	Tracing TracingConfig `yaml:"tracing"`
+	// Storage - используемое хранилище: mongo или memory
+	Storage  string         `yaml:"storage"`
+	Mongo    MongoConfig    `yaml:"mongo"`
+	JWT      JWTConfig      `yaml:"jwt"`
+	Password PasswordConfig `yaml:"password"`
+	Account  AccountConfig  `yaml:"account"`
+	Media    MediaConfig    `yaml:"media"`
+
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Trash         TrashConfig         `yaml:"trash"`
+	RateLimit     RateLimitConfig     `yaml:"rate_limit"`
+	Lockout       LockoutConfig       `yaml:"login_lockout"`
+}
+
+// Default возвращает конфигурацию со значениями по умолчанию.
+// Секрет JWT значения по умолчанию не имеет и должен быть задан явно
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Address:         ":8080",
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 20 * time.Second,
+			LegacyRoutes:    true,
+		},
+		Log:      LogConfig{Level: "info", Format: "json"},
+		Tracing:  TracingConfig{Exporter: "none", ServiceName: "pet-api", SampleRatio: 1},
+		Storage:  "mongo",
+		Mongo:    MongoConfig{URI: "mongodb://localhost:27017", Database: "testdb"},
+		JWT:      JWTConfig{AccessTTL: 15 * time.Minute, RefreshTTL: 30 * 24 * time.Hour},
+		Password: PasswordConfig{BcryptCost: 12},
+		Account:  AccountConfig{VerificationTTL: 48 * time.Hour, ResetTTL: time.Hour},
+		Media: MediaConfig{Dir: "uploads", BaseURL: "/media", MaxPhotoSize: 10 << 20, ThumbnailSize: 320,
+			MaxPhotoDimension: 10000, MaxPhotoPixels: 40_000_000},
+
+		Notifications: NotificationsConfig{DigestInterval: 24 * time.Hour, SMTP: SMTPConfig{Port: 587}},
+		Trash:         TrashConfig{Retention: 30 * 24 * time.Hour, PurgeInterval: time.Hour},
+		RateLimit: RateLimitConfig{
+			Enabled:                true,
+			Backend:                "memory",
+			LoginPerIP:             Rate{Requests: 20, Period: time.Minute},
+			LoginPerUsername:       Rate{Requests: 10, Period: time.Minute},
+			RegisterPerIP:          Rate{Requests: 5, Period: time.Hour},
+			AccountEmailPerIP:      Rate{Requests: 5, Period: time.Hour},
+			AccountEmailPerAddress: Rate{Requests: 3, Period: time.Hour},
+		},
+		Lockout: LockoutConfig{Threshold: 5, Duration: time.Minute, MaxDuration: time.Hour, ResetAfter: 24 * time.Hour},
+	}
+}
+
+// Load загружает конфигурацию: значения по умолчанию перекрываются YAML-файлом path (если он задан),
+// а затем переменными окружения. Загруженная конфигурация проверяется
+func Load(path string) (*Config, error) {
+	config := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+
+	if err := config.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// applyEnv перекрывает значения конфигурации переменными окружения
+func (config *Config) applyEnv() error {
+	setString := func(name string, target *string) {
+		if value, ok := os.LookupEnv(name); ok {
+			*target = value
+		}
+	}
+	setSecret := func(name string, target *Secret) {
+		if value, ok := os.LookupEnv(name); ok {
+			*target = Secret(value)
+		}
+	}
+	setDuration := func(name string, target *time.Duration) error {
+		if value, ok := os.LookupEnv(name); ok {
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			*target = duration
+		}
+		return nil
+	}
+
+	setString("SERVER_ADDRESS", &config.Server.Address)
+	if port, ok := os.LookupEnv("PORT"); ok {
+		config.Server.Address = ":" + port
+	}
+	if err := setDuration("SERVER_SHUTDOWN_TIMEOUT", &config.Server.ShutdownTimeout); err != nil {
+		return err
+	}
+	if value, ok := os.LookupEnv("SERVER_LEGACY_ROUTES"); ok {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("SERVER_LEGACY_ROUTES: %w", err)
+		}
+		config.Server.LegacyRoutes = enabled
+	}
+	if value, ok := os.LookupEnv("SERVER_LEGACY_SUNSET"); ok {
+		sunset, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return fmt.Errorf("SERVER_LEGACY_SUNSET: %w", err)
+		}
+		config.Server.LegacySunset = sunset
+	}
+	if value, ok := os.LookupEnv("SERVER_TRUSTED_PROXIES"); ok {
+		config.Server.TrustedProxies = nil
+		for _, proxy := range strings.Split(value, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				config.Server.TrustedProxies = append(config.Server.TrustedProxies, proxy)
+			}
+		}
+	}
+	setString("LOG_LEVEL", &config.Log.Level)
+	setString("LOG_FORMAT", &config.Log.Format)
+	setString("TRACING_EXPORTER", &config.Tracing.Exporter)
+	setString("TRACING_ENDPOINT", &config.Tracing.Endpoint)
+	if value, ok := os.LookupEnv("TRACING_SAMPLE_RATIO"); ok {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("TRACING_SAMPLE_RATIO: %w", err)
+		}
+		config.Tracing.SampleRatio = ratio
+	}
+	setString("STORAGE", &config.Storage)
+	setSecret("MONGO_URI", &config.Mongo.URI)
+	setString("MONGO_DATABASE", &config.Mongo.Database)
+	setSecret("JWT_SECRET", &config.JWT.Secret)
+	if err := setDuration("JWT_ACCESS_TTL", &config.JWT.AccessTTL); err != nil {
+		return err
+	}
+	if err := setDuration("JWT_REFRESH_TTL", &config.JWT.RefreshTTL); err != nil {
+		return err
+	}
+	if value, ok := os.LookupEnv("BCRYPT_COST"); ok {
+		cost, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("BCRYPT_COST: %w", err)
+		}
+		config.Password.BcryptCost = cost
+	}
+	if value, ok := os.LookupEnv("ACCOUNT_REQUIRE_VERIFIED_EMAIL"); ok {
+		required, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("ACCOUNT_REQUIRE_VERIFIED_EMAIL: %w", err)
+		}
+		config.Account.RequireVerifiedEmail = required
+	}
+	setString("ACCOUNT_VERIFY_URL", &config.Account.VerifyURL)
+	setString("ACCOUNT_RESET_URL", &config.Account.ResetURL)
+	setString("ACCOUNT_MAIL_DIR", &config.Account.MailDir)
+	setString("MEDIA_DIR", &config.Media.Dir)
+	if err := setDuration("DIGEST_INTERVAL", &config.Notifications.DigestInterval); err != nil {
+		return err
+	}
+	setString("SMTP_HOST", &config.Notifications.SMTP.Host)
+	setString("SMTP_USERNAME", &config.Notifications.SMTP.Username)
+	setSecret("SMTP_PASSWORD", &config.Notifications.SMTP.Password)
+	setString("SMTP_FROM", &config.Notifications.SMTP.From)
+	if err := setDuration("TRASH_RETENTION", &config.Trash.Retention); err != nil {
+		return err
+	}
+	if err := setDuration("TRASH_PURGE_INTERVAL", &config.Trash.PurgeInterval); err != nil {
+		return err
+	}
+
+	if value, ok := os.LookupEnv("RATE_LIMIT_ENABLED"); ok {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("RATE_LIMIT_ENABLED: %w", err)
+		}
+		config.RateLimit.Enabled = enabled
+	}
+	if value, ok := os.LookupEnv("LOGIN_LOCKOUT_THRESHOLD"); ok {
+		threshold, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("LOGIN_LOCKOUT_THRESHOLD: %w", err)
+		}
+		config.Lockout.Threshold = threshold
+	}
+
+	return nil
+}
+
+// Validate проверяет корректность конфигурации и возвращает все найденные ошибки
+func (config *Config) Validate() error {
+	var problems []string
+
+	if config.Server.Address == "" {
+		problems = append(problems, "server.address is required")
+	}
+	if config.Server.ReadTimeout <= 0 || config.Server.WriteTimeout <= 0 || config.Server.IdleTimeout <= 0 || config.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server.read_timeout, server.write_timeout, server.idle_timeout and server.shutdown_timeout must be positive")
+	}
+	switch strings.ToLower(config.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, "log.level must be debug, info, warn or error")
+	}
+	if config.Log.Format != "json" && config.Log.Format != "text" {
+		problems = append(problems, "log.format must be json or text")
+	}
+	switch config.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		problems = append(problems, "tracing.exporter must be none, stdout or otlp")
+	}
+	if config.Tracing.ServiceName == "" {
+		problems = append(problems, "tracing.service_name is required")
+	}
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		problems = append(problems, "tracing.sample_ratio must be between 0 and 1")
+	}
+	switch config.Storage {
+	case "memory":
+	case "mongo":
+		if config.Mongo.URI == "" {
+			problems = append(problems, "mongo.uri is required")
+		}
+		if config.Mongo.Database == "" {
+			problems = append(problems, "mongo.database is required")
+		}
+	default:
+		problems = append(problems, "storage must be mongo or memory")
+	}
+	if len(config.JWT.Secret) < 16 {
+		problems = append(problems, "jwt.secret must be at least 16 characters")
+	}
+	if config.JWT.AccessTTL <= 0 || config.JWT.RefreshTTL <= 0 {
+		problems = append(problems, "jwt.access_ttl and jwt.refresh_ttl must be positive")
+	}
+	if config.JWT.AccessTTL >= config.JWT.RefreshTTL {
+		problems = append(problems, "jwt.access_ttl must be shorter than jwt.refresh_ttl")
+	}
+	// Границы совпадают с bcrypt.MinCost и bcrypt.MaxCost
+	if config.Password.BcryptCost < 4 || config.Password.BcryptCost > 31 {
+		problems = append(problems, "password.bcrypt_cost must be between 4 and 31")
+	}
+	if config.Account.VerificationTTL <= 0 || config.Account.ResetTTL <= 0 {
+		problems = append(problems, "account.verification_ttl and account.reset_ttl must be positive")
+	}
+	if config.Media.Dir == "" || config.Media.BaseURL == "" {
+		problems = append(problems, "media.dir and media.base_url are required")
+	}
+	if config.Media.MaxPhotoSize <= 0 || config.Media.ThumbnailSize <= 0 {
+		problems = append(problems, "media.max_photo_size and media.thumbnail_size must be positive")
+	}
+	if config.Media.MaxPhotoDimension <= 0 || config.Media.MaxPhotoPixels <= 0 {
+		problems = append(problems, "media.max_photo_dimension and media.max_photo_pixels must be positive")
+	}
+	if config.Notifications.DigestInterval <= 0 {
+		problems = append(problems, "notifications.digest_interval must be positive")
+	}
+	if smtp := config.Notifications.SMTP; smtp.Host != "" && (smtp.Port <= 0 || smtp.From == "") {
+		problems = append(problems, "notifications.smtp.port and notifications.smtp.from are required when smtp.host is set")
+	}
+	if config.Trash.Retention <= 0 || config.Trash.PurgeInterval <= 0 {
+		problems = append(problems, "trash.retention and trash.purge_interval must be positive")
+	}
+
+	if config.RateLimit.Enabled {
+		if config.RateLimit.Backend != "memory" {
+			problems = append(problems, "rate_limit.backend must be memory")
+		}
+		rates := []struct {
+			name string
+			rate Rate
+		}{
+			{"login_per_ip", config.RateLimit.LoginPerIP},
+			{"login_per_username", config.RateLimit.LoginPerUsername},
+			{"register_per_ip", config.RateLimit.RegisterPerIP},
+			{"account_email_per_ip", config.RateLimit.AccountEmailPerIP},
+			{"account_email_per_address", config.RateLimit.AccountEmailPerAddress},
+		}
+		for _, limit := range rates {
+			if limit.rate.Requests <= 0 || limit.rate.Period <= 0 {
+				problems = append(problems, "rate_limit."+limit.name+".requests and rate_limit."+limit.name+".period must be positive")
+			}
+		}
+	}
+	if lockout := config.Lockout; lockout.Threshold < 0 {
+		problems = append(problems, "login_lockout.threshold must not be negative")
+	} else if lockout.Threshold > 0 {
+		if lockout.Duration <= 0 || lockout.MaxDuration < lockout.Duration {
+			problems = append(problems, "login_lockout.duration must be positive and not longer than login_lockout.max_duration")
+		}
+		if lockout.ResetAfter < lockout.MaxDuration {
+			problems = append(problems, "login_lockout.reset_after must not be shorter than login_lockout.max_duration")
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryApplicationStore - потокобезопасная реализация ApplicationStore в оперативной памяти
+type MemoryApplicationStore struct {
+	mutex        sync.RWMutex
+	applications map[primitive.ObjectID]models.Application
+	order        []primitive.ObjectID
+}
+
+func CreateMemoryApplicationStore() *MemoryApplicationStore {
+	return &MemoryApplicationStore{applications: map[primitive.ObjectID]models.Application{}}
+}
+
+func (store *MemoryApplicationStore) GetApplication(ctx context.Context, id primitive.ObjectID) (*models.Application, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	application, ok := store.applications[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &application, nil
+}
+
+func (store *MemoryApplicationStore) FindApplications(ctx context.Context, filter ApplicationFilter) ([]models.Application, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	applications := []models.Application{}
+	for _, id := range store.order {
+		application := store.applications[id]
+		if !filter.PetID.IsZero() && application.PetID != filter.PetID {
+			continue
+		}
+		if !filter.UserID.IsZero() && application.UserID != filter.UserID {
+			continue
+		}
+		if filter.Status != "" && application.Status != filter.Status {
+			continue
+		}
+		applications = append(applications, application)
+	}
+
+	return applications, nil
+}
+
+func (store *MemoryApplicationStore) CreateApplication(ctx context.Context, application *models.Application) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	application.ID = primitive.NewObjectID()
+	store.applications[application.ID] = *application
+	store.order = append(store.order, application.ID)
+	return nil
+}
+
+func (store *MemoryApplicationStore) UpdateApplicationStatus(ctx context.Context, id primitive.ObjectID, from, to, comment string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	application, ok := store.applications[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if application.Status != from {
+		return ErrConflict
+	}
+
+	application.Status = to
+	application.Comment = comment
+	application.UpdatedAt = time.Now()
+	store.applications[id] = application
+	return nil
+}
+
+func (store *MemoryApplicationStore) CloseApplicationsForPet(ctx context.Context, petID, except primitive.ObjectID, comment string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for id, application := range store.applications {
+		if application.PetID != petID || id == except || !application.IsOpen() {
+			continue
+		}
+		application.Status = models.ApplicationRejected
+		application.Comment = comment
+		application.UpdatedAt = time.Now()
+		store.applications[id] = application
+	}
+	return nil
+}
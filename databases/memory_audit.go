@@ -0,0 +1,66 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryAuditStore - потокобезопасная реализация AuditStore в оперативной памяти
+type MemoryAuditStore struct {
+	mutex sync.RWMutex
+	// entries хранятся в порядке добавления
+	entries []models.AuditEntry
+}
+
+func CreateMemoryAuditStore() *MemoryAuditStore {
+	return &MemoryAuditStore{}
+}
+
+func (store *MemoryAuditStore) RecordAudit(ctx context.Context, entry *models.AuditEntry) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry.ID = primitive.NewObjectID()
+	store.entries = append(store.entries, *entry)
+	return nil
+}
+
+func (store *MemoryAuditStore) FindAudit(ctx context.Context, filter AuditFilter, limit int) ([]models.AuditEntry, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	entries := []models.AuditEntry{}
+	for i := len(store.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		if filter.matches(&store.entries[i]) {
+			entries = append(entries, store.entries[i])
+		}
+	}
+
+	return entries, nil
+}
+
+// matches проверяет, подходит ли запись журнала под фильтр
+func (filter AuditFilter) matches(entry *models.AuditEntry) bool {
+	if !filter.ActorID.IsZero() && entry.ActorID != filter.ActorID {
+		return false
+	}
+	if filter.Entity != "" && entry.Entity != filter.Entity {
+		return false
+	}
+	if filter.EntityID != "" && entry.EntityID != filter.EntityID {
+		return false
+	}
+	if filter.Action != "" && entry.Action != filter.Action {
+		return false
+	}
+	if !filter.From.IsZero() && entry.Timestamp.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !entry.Timestamp.Before(filter.To) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// favoriteKey - ключ записи избранного в MemoryFavoriteStore
+type favoriteKey struct {
+	userID primitive.ObjectID
+	petID  primitive.ObjectID
+}
+
+// MemoryFavoriteStore - потокобезопасная реализация FavoriteStore в оперативной памяти
+type MemoryFavoriteStore struct {
+	mutex     sync.RWMutex
+	favorites map[favoriteKey]models.Favorite
+}
+
+func CreateMemoryFavoriteStore() *MemoryFavoriteStore {
+	return &MemoryFavoriteStore{favorites: map[favoriteKey]models.Favorite{}}
+}
+
+func (store *MemoryFavoriteStore) AddFavorite(ctx context.Context, favorite *models.Favorite) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	key := favoriteKey{userID: favorite.UserID, petID: favorite.PetID}
+	if existing, ok := store.favorites[key]; ok {
+		*favorite = existing
+		return nil
+	}
+
+	store.favorites[key] = *favorite
+	return nil
+}
+
+func (store *MemoryFavoriteStore) RemoveFavorite(ctx context.Context, userID, petID primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	key := favoriteKey{userID: userID, petID: petID}
+	if _, ok := store.favorites[key]; !ok {
+		return ErrNotFound
+	}
+
+	delete(store.favorites, key)
+	return nil
+}
+
+func (store *MemoryFavoriteStore) FindFavorites(ctx context.Context, userID primitive.ObjectID) ([]models.Favorite, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	favorites := []models.Favorite{}
+	for key, favorite := range store.favorites {
+		if key.userID == userID {
+			favorites = append(favorites, favorite)
+		}
+	}
+	sort.Slice(favorites, func(i, j int) bool { return favorites[i].CreatedAt.After(favorites[j].CreatedAt) })
+
+	return favorites, nil
+}
+
+func (store *MemoryFavoriteStore) CountFavorites(ctx context.Context, petIDs []primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	wanted := map[primitive.ObjectID]bool{}
+	for _, petID := range petIDs {
+		wanted[petID] = true
+	}
+
+	counts := map[primitive.ObjectID]int{}
+	for key := range store.favorites {
+		if wanted[key.petID] {
+			counts[key.petID]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (store *MemoryFavoriteStore) FavoritePetIDs(ctx context.Context, userID primitive.ObjectID, petIDs []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	favorited := map[primitive.ObjectID]bool{}
+	for _, petID := range petIDs {
+		if _, ok := store.favorites[favoriteKey{userID: userID, petID: petID}]; ok {
+			favorited[petID] = true
+		}
+	}
+
+	return favorited, nil
+}
+
+func (store *MemoryFavoriteStore) DeleteFavoritesForPet(ctx context.Context, petID primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for key := range store.favorites {
+		if key.petID == petID {
+			delete(store.favorites, key)
+		}
+	}
+	return nil
+}
+
+func (store *MemoryFavoriteStore) DeleteFavoritesForUser(ctx context.Context, userID primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for key := range store.favorites {
+		if key.userID == userID {
+			delete(store.favorites, key)
+		}
+	}
+	return nil
+}
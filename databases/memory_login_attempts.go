@@ -0,0 +1,87 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"sync"
+	"time"
+)
+
+// loginAttemptsCleanupInterval - как часто удаляются истёкшие записи о попытках входа
+const loginAttemptsCleanupInterval = time.Minute
+
+// MemoryLoginAttemptStore - потокобезопасная реализация LoginAttemptStore в оперативной памяти
+type MemoryLoginAttemptStore struct {
+	mutex    sync.Mutex
+	attempts map[string]models.LoginAttempts
+	// cleaned - время последнего удаления истёкших записей
+	cleaned time.Time
+}
+
+func CreateMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{attempts: map[string]models.LoginAttempts{}, cleaned: time.Now()}
+}
+
+func (store *MemoryLoginAttemptStore) GetLoginAttempts(ctx context.Context, username string) (*models.LoginAttempts, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	attempts, ok := store.attempts[username]
+	if !ok || !attempts.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return &attempts, nil
+}
+
+func (store *MemoryLoginAttemptStore) RecordLoginFailure(ctx context.Context, username string, expiresAt time.Time) (*models.LoginAttempts, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	store.removeExpired(now)
+	// Истёкшая запись, которую ещё не удалили, не отличается от отсутствующей
+	attempts, ok := store.attempts[username]
+	if !ok || !attempts.ExpiresAt.After(now) {
+		attempts = models.LoginAttempts{Username: username}
+	}
+	attempts.Failures++
+	attempts.ExpiresAt = expiresAt
+	store.attempts[username] = attempts
+	return &attempts, nil
+}
+
+func (store *MemoryLoginAttemptStore) LockLogin(ctx context.Context, username string, until time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	attempts, ok := store.attempts[username]
+	if !ok {
+		return ErrNotFound
+	}
+	attempts.LockedUntil = until
+	store.attempts[username] = attempts
+	return nil
+}
+
+func (store *MemoryLoginAttemptStore) ResetLoginAttempts(ctx context.Context, username string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.attempts, username)
+	return nil
+}
+
+// removeExpired удаляет истёкшие записи, заменяя TTL-индекс MongoDB. Проверка выполняется
+// не чаще раза в loginAttemptsCleanupInterval, чтобы поток неудачных попыток не перебирал
+// все записи при каждой из них. Вызывается под блокировкой
+func (store *MemoryLoginAttemptStore) removeExpired(now time.Time) {
+	if now.Sub(store.cleaned) < loginAttemptsCleanupInterval {
+		return
+	}
+	store.cleaned = now
+	for username, attempts := range store.attempts {
+		if !attempts.ExpiresAt.After(now) {
+			delete(store.attempts, username)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryNotificationStore - потокобезопасная реализация NotificationStore в оперативной памяти
+type MemoryNotificationStore struct {
+	mutex sync.RWMutex
+	// notifications хранятся в порядке добавления
+	notifications []models.Notification
+}
+
+func CreateMemoryNotificationStore() *MemoryNotificationStore {
+	return &MemoryNotificationStore{}
+}
+
+func (store *MemoryNotificationStore) CreateNotification(ctx context.Context, notification *models.Notification) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	notification.ID = primitive.NewObjectID()
+	store.notifications = append(store.notifications, *notification)
+	return nil
+}
+
+func (store *MemoryNotificationStore) FindNotifications(ctx context.Context, userID primitive.ObjectID) ([]models.Notification, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	notifications := []models.Notification{}
+	for i := len(store.notifications) - 1; i >= 0; i-- {
+		if store.notifications[i].UserID == userID {
+			notifications = append(notifications, store.notifications[i])
+		}
+	}
+
+	return notifications, nil
+}
+
+func (store *MemoryNotificationStore) MarkNotificationRead(ctx context.Context, userID, id primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for i := range store.notifications {
+		if store.notifications[i].ID == id && store.notifications[i].UserID == userID {
+			store.notifications[i].Read = true
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (store *MemoryNotificationStore) DeleteNotificationsForUser(ctx context.Context, userID primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	notifications := store.notifications[:0]
+	for _, notification := range store.notifications {
+		if notification.UserID != userID {
+			notifications = append(notifications, notification)
+		}
+	}
+	store.notifications = notifications
+	return nil
+}
@@ -0,0 +1,310 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryPetStore - потокобезопасная реализация PetStore в оперативной памяти.
+// Используется для локального запуска без MongoDB
+type MemoryPetStore struct {
+	mutex sync.RWMutex
+	pets  map[primitive.ObjectID]models.Pet
+	order []primitive.ObjectID
+	// history - история статусов всех животных в порядке добавления
+	history []models.PetStatusChange
+}
+
+func CreateMemoryPetStore() *MemoryPetStore {
+	return &MemoryPetStore{pets: map[primitive.ObjectID]models.Pet{}}
+}
+
+func (store *MemoryPetStore) GetPet(ctx context.Context, id primitive.ObjectID) (*models.Pet, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	pet, ok := store.activePet(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &pet, nil
+}
+
+func (store *MemoryPetStore) GetPets(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]models.Pet, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	pets := map[primitive.ObjectID]models.Pet{}
+	for _, id := range ids {
+		if pet, ok := store.activePet(id); ok {
+			pets[id] = pet
+		}
+	}
+	return pets, nil
+}
+
+func (store *MemoryPetStore) FindPets(ctx context.Context, filter PetFilter, page PageRequest) (*PetPage, error) {
+	page = page.withDefaults()
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	pets := []models.Pet{}
+	for _, id := range store.order {
+		pet := store.pets[id]
+		if pet.DeletedAt == nil && filter.Matches(&pet) {
+			pets = append(pets, pet)
+		}
+	}
+
+	sort.SliceStable(pets, func(i, j int) bool {
+		if page.Desc {
+			return comparePets(&pets[i], &pets[j], page.Sort) > 0
+		}
+		return comparePets(&pets[i], &pets[j], page.Sort) < 0
+	})
+
+	result := &PetPage{Total: int64(len(pets))}
+
+	start := page.Offset
+	if page.Cursor != "" {
+		value, id, err := decodePetCursor(page)
+		if err != nil {
+			return nil, err
+		}
+
+		// Ищем первый элемент, идущий после курсора
+		last := models.Pet{ID: id}
+		setPetSortValue(&last, page.Sort, value)
+		start = sort.Search(len(pets), func(i int) bool {
+			if page.Desc {
+				return comparePets(&pets[i], &last, page.Sort) < 0
+			}
+			return comparePets(&pets[i], &last, page.Sort) > 0
+		})
+	}
+
+	if start > len(pets) {
+		start = len(pets)
+	}
+	end := min(start+page.Limit, len(pets))
+	result.Pets = pets[start:end]
+	if end < len(pets) && end > start {
+		result.NextCursor = encodePetCursor(page, &pets[end-1])
+	}
+
+	return result, nil
+}
+
+func (store *MemoryPetStore) CreatePet(ctx context.Context, pet *models.Pet) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	pet.ID = primitive.NewObjectID()
+	store.pets[pet.ID] = *pet
+	store.order = append(store.order, pet.ID)
+	return nil
+}
+
+func (store *MemoryPetStore) UpdatePet(ctx context.Context, id primitive.ObjectID, pet *models.Pet) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	current, ok := store.activePet(id)
+	if !ok {
+		return ErrNotFound
+	}
+
+	current.ShelterID = pet.ShelterID
+	current.Name = pet.Name
+	current.Age = pet.Age
+	current.Gender = pet.Gender
+	current.Species = pet.Species
+	current.Breed = pet.Breed
+	current.Description = pet.Description
+	store.pets[id] = current
+	return nil
+}
+
+func (store *MemoryPetStore) DeletePet(ctx context.Context, id primitive.ObjectID, deletedBy primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	pet, ok := store.activePet(id)
+	if !ok {
+		return ErrNotFound
+	}
+
+	now := time.Now()
+	pet.DeletedAt = &now
+	pet.DeletedBy = &deletedBy
+	store.pets[id] = pet
+	return nil
+}
+
+func (store *MemoryPetStore) GetDeletedPet(ctx context.Context, id primitive.ObjectID) (*models.Pet, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	pet, ok := store.pets[id]
+	if !ok || pet.DeletedAt == nil {
+		return nil, ErrNotFound
+	}
+
+	return &pet, nil
+}
+
+func (store *MemoryPetStore) FindDeletedPets(ctx context.Context, shelterID primitive.ObjectID) ([]models.Pet, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	pets := []models.Pet{}
+	for _, pet := range store.pets {
+		if pet.DeletedAt != nil && (shelterID.IsZero() || pet.ShelterID == shelterID) {
+			pets = append(pets, pet)
+		}
+	}
+	sort.Slice(pets, func(i, j int) bool { return pets[i].DeletedAt.After(*pets[j].DeletedAt) })
+
+	return pets, nil
+}
+
+func (store *MemoryPetStore) RestorePet(ctx context.Context, id primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	pet, ok := store.pets[id]
+	if !ok || pet.DeletedAt == nil {
+		return ErrNotFound
+	}
+
+	pet.DeletedAt = nil
+	pet.DeletedBy = nil
+	store.pets[id] = pet
+	return nil
+}
+
+func (store *MemoryPetStore) PurgeDeletedPets(ctx context.Context, deletedBefore time.Time) ([]models.Pet, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	purged := []models.Pet{}
+	order := store.order[:0]
+	for _, id := range store.order {
+		pet := store.pets[id]
+		if pet.DeletedAt != nil && pet.DeletedAt.Before(deletedBefore) {
+			purged = append(purged, pet)
+			delete(store.pets, id)
+			continue
+		}
+		order = append(order, id)
+	}
+	store.order = order
+
+	return purged, nil
+}
+
+// activePet возвращает животное, не находящееся в корзине. Вызывается под блокировкой
+func (store *MemoryPetStore) activePet(id primitive.ObjectID) (models.Pet, bool) {
+	pet, ok := store.pets[id]
+	if !ok || pet.DeletedAt != nil {
+		return models.Pet{}, false
+	}
+	return pet, true
+}
+
+func (store *MemoryPetStore) SetPetStatus(ctx context.Context, change *models.PetStatusChange) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	pet, ok := store.activePet(change.PetID)
+	if !ok {
+		return ErrNotFound
+	}
+	if pet.Status != change.From {
+		return ErrConflict
+	}
+
+	pet.Status = change.To
+	store.pets[change.PetID] = pet
+	store.addPetStatusChange(change)
+	return nil
+}
+
+func (store *MemoryPetStore) AddPetStatusChange(ctx context.Context, change *models.PetStatusChange) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.addPetStatusChange(change)
+	return nil
+}
+
+// addPetStatusChange добавляет запись в историю статусов. Вызывается под блокировкой
+func (store *MemoryPetStore) addPetStatusChange(change *models.PetStatusChange) {
+	change.ID = primitive.NewObjectID()
+	store.history = append(store.history, *change)
+}
+
+func (store *MemoryPetStore) FindPetStatusChanges(ctx context.Context, petID primitive.ObjectID) ([]models.PetStatusChange, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	changes := []models.PetStatusChange{}
+	for _, change := range store.history {
+		if change.PetID == petID {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+func (store *MemoryPetStore) AddPetPhoto(ctx context.Context, id primitive.ObjectID, photo *models.Photo) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	pet, ok := store.activePet(id)
+	if !ok {
+		return ErrNotFound
+	}
+
+	// Копируем срез, чтобы не изменять данные, уже отданные вызывающему коду
+	pet.Photos = append(append([]models.Photo{}, pet.Photos...), *photo)
+	store.pets[id] = pet
+	return nil
+}
+
+func (store *MemoryPetStore) SetPetPhotos(ctx context.Context, id primitive.ObjectID, current, photos []models.Photo) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	pet, ok := store.activePet(id)
+	if !ok {
+		return ErrNotFound
+	}
+	if !slices.Equal(pet.Photos, current) {
+		return ErrConflict
+	}
+
+	pet.Photos = append([]models.Photo{}, photos...)
+	store.pets[id] = pet
+	return nil
+}
+
+// setPetSortValue записывает значение поля сортировки, полученное из курсора
+func setPetSortValue(pet *models.Pet, sort string, value interface{}) {
+	switch sort {
+	case PetSortName:
+		pet.Name = value.(string)
+	case PetSortAge:
+		pet.Age = value.(int)
+	default:
+		pet.CreatedAt = value.(time.Time)
+	}
+}
@@ -0,0 +1,65 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"sort"
+	"sync"
+)
+
+// MemoryRoleStore - потокобезопасная реализация RoleStore в оперативной памяти
+type MemoryRoleStore struct {
+	mutex sync.RWMutex
+	roles map[string]models.Role
+}
+
+func CreateMemoryRoleStore() *MemoryRoleStore {
+	return &MemoryRoleStore{roles: map[string]models.Role{}}
+}
+
+func (store *MemoryRoleStore) GetRole(ctx context.Context, name string) (*models.Role, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	role, ok := store.roles[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &role, nil
+}
+
+func (store *MemoryRoleStore) FindRoles(ctx context.Context) ([]models.Role, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	roles := []models.Role{}
+	for _, role := range store.roles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+
+	return roles, nil
+}
+
+func (store *MemoryRoleStore) SaveRole(ctx context.Context, role *models.Role) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	saved := *role
+	saved.Permissions = append([]string{}, role.Permissions...)
+	store.roles[role.Name] = saved
+	return nil
+}
+
+func (store *MemoryRoleStore) DeleteRole(ctx context.Context, name string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.roles[name]; !ok {
+		return ErrNotFound
+	}
+
+	delete(store.roles, name)
+	return nil
+}
@@ -0,0 +1,122 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemorySearchStore - потокобезопасная реализация SearchStore в оперативной памяти
+type MemorySearchStore struct {
+	mutex    sync.RWMutex
+	searches map[primitive.ObjectID]models.SavedSearch
+	// matches хранятся в порядке добавления
+	matches []models.SearchMatch
+}
+
+func CreateMemorySearchStore() *MemorySearchStore {
+	return &MemorySearchStore{searches: map[primitive.ObjectID]models.SavedSearch{}}
+}
+
+func (store *MemorySearchStore) CreateSavedSearch(ctx context.Context, search *models.SavedSearch) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	search.ID = primitive.NewObjectID()
+	store.searches[search.ID] = *search
+	return nil
+}
+
+func (store *MemorySearchStore) GetSavedSearch(ctx context.Context, id primitive.ObjectID) (*models.SavedSearch, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	search, ok := store.searches[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &search, nil
+}
+
+func (store *MemorySearchStore) FindSavedSearches(ctx context.Context, userID primitive.ObjectID) ([]models.SavedSearch, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	searches := []models.SavedSearch{}
+	for _, search := range store.searches {
+		if userID.IsZero() || search.UserID == userID {
+			searches = append(searches, search)
+		}
+	}
+	sort.Slice(searches, func(i, j int) bool { return searches[i].CreatedAt.Before(searches[j].CreatedAt) })
+
+	return searches, nil
+}
+
+func (store *MemorySearchStore) DeleteSavedSearch(ctx context.Context, id primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.searches[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(store.searches, id)
+	matches := store.matches[:0]
+	for _, match := range store.matches {
+		if match.SearchID != id {
+			matches = append(matches, match)
+		}
+	}
+	store.matches = matches
+	return nil
+}
+
+func (store *MemorySearchStore) AddSearchMatch(ctx context.Context, match *models.SearchMatch) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, existing := range store.matches {
+		if existing.SearchID == match.SearchID && existing.PetID == match.PetID {
+			return ErrConflict
+		}
+	}
+
+	match.ID = primitive.NewObjectID()
+	store.matches = append(store.matches, *match)
+	return nil
+}
+
+func (store *MemorySearchStore) FindPendingMatches(ctx context.Context) ([]models.SearchMatch, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	matches := []models.SearchMatch{}
+	for _, match := range store.matches {
+		if !match.Notified {
+			matches = append(matches, match)
+		}
+	}
+
+	return matches, nil
+}
+
+func (store *MemorySearchStore) MarkMatchesNotified(ctx context.Context, ids []primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	notified := map[primitive.ObjectID]bool{}
+	for _, id := range ids {
+		notified[id] = true
+	}
+	for i := range store.matches {
+		if notified[store.matches[i].ID] {
+			store.matches[i].Notified = true
+		}
+	}
+	return nil
+}
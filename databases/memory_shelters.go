@@ -0,0 +1,80 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryShelterStore - потокобезопасная реализация ShelterStore в оперативной памяти
+type MemoryShelterStore struct {
+	mutex    sync.RWMutex
+	shelters map[primitive.ObjectID]models.Shelter
+}
+
+func CreateMemoryShelterStore() *MemoryShelterStore {
+	return &MemoryShelterStore{shelters: map[primitive.ObjectID]models.Shelter{}}
+}
+
+func (store *MemoryShelterStore) GetShelter(ctx context.Context, id primitive.ObjectID) (*models.Shelter, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	shelter, ok := store.shelters[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &shelter, nil
+}
+
+func (store *MemoryShelterStore) FindShelters(ctx context.Context) ([]models.Shelter, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	shelters := []models.Shelter{}
+	for _, shelter := range store.shelters {
+		shelters = append(shelters, shelter)
+	}
+	sort.Slice(shelters, func(i, j int) bool { return shelters[i].Name < shelters[j].Name })
+
+	return shelters, nil
+}
+
+func (store *MemoryShelterStore) CreateShelter(ctx context.Context, shelter *models.Shelter) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	shelter.ID = primitive.NewObjectID()
+	store.shelters[shelter.ID] = *shelter
+	return nil
+}
+
+func (store *MemoryShelterStore) UpdateShelter(ctx context.Context, id primitive.ObjectID, shelter *models.Shelter) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.shelters[id]; !ok {
+		return ErrNotFound
+	}
+
+	updated := *shelter
+	updated.ID = id
+	store.shelters[id] = updated
+	return nil
+}
+
+func (store *MemoryShelterStore) DeleteShelter(ctx context.Context, id primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.shelters[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(store.shelters, id)
+	return nil
+}
@@ -0,0 +1,194 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryTokenStore - потокобезопасная реализация TokenStore в оперативной памяти
+type MemoryTokenStore struct {
+	mutex         sync.RWMutex
+	refreshTokens map[string]models.RefreshToken
+	revokedTokens map[string]time.Time
+	accountTokens map[string]models.AccountToken
+}
+
+func CreateMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		refreshTokens: map[string]models.RefreshToken{},
+		revokedTokens: map[string]time.Time{},
+		accountTokens: map[string]models.AccountToken{},
+	}
+}
+
+func (store *MemoryTokenStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.removeExpired()
+	token.ID = primitive.NewObjectID()
+	store.refreshTokens[token.Hash] = *token
+	return nil
+}
+
+func (store *MemoryTokenStore) GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	token, ok := store.refreshTokens[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &token, nil
+}
+
+func (store *MemoryTokenStore) MarkRefreshTokenUsed(ctx context.Context, id primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for hash, token := range store.refreshTokens {
+		if token.ID != id {
+			continue
+		}
+		if token.Used || token.Revoked {
+			return ErrConflict
+		}
+		token.Used = true
+		store.refreshTokens[hash] = token
+		return nil
+	}
+
+	return ErrConflict
+}
+
+func (store *MemoryTokenStore) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for hash, token := range store.refreshTokens {
+		if token.FamilyID == familyID {
+			token.Revoked = true
+			store.refreshTokens[hash] = token
+		}
+	}
+	return nil
+}
+
+func (store *MemoryTokenStore) RevokeUserTokens(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	families := map[string]bool{}
+	for hash, token := range store.refreshTokens {
+		if token.UserID != userID {
+			continue
+		}
+		token.Revoked = true
+		store.refreshTokens[hash] = token
+		if token.ExpiresAt.After(now) {
+			families[token.FamilyID] = true
+		}
+	}
+
+	familyIDs := make([]string, 0, len(families))
+	for familyID := range families {
+		familyIDs = append(familyIDs, familyID)
+	}
+	return familyIDs, nil
+}
+
+func (store *MemoryTokenStore) RevokeToken(ctx context.Context, id string, expiresAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if expiresAt.After(store.revokedTokens[id]) {
+		store.revokedTokens[id] = expiresAt
+	}
+	return nil
+}
+
+func (store *MemoryTokenStore) IsTokenRevoked(ctx context.Context, ids ...string) (bool, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		if expiresAt, ok := store.revokedTokens[id]; ok && expiresAt.After(now) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (store *MemoryTokenStore) CreateAccountToken(ctx context.Context, token *models.AccountToken) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.removeExpired()
+	token.ID = primitive.NewObjectID()
+	store.accountTokens[token.Hash] = *token
+	return nil
+}
+
+func (store *MemoryTokenStore) GetAccountToken(ctx context.Context, hash, purpose string) (*models.AccountToken, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	token, ok := store.accountTokens[hash]
+	if !ok || token.Purpose != purpose || token.Used || !token.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return &token, nil
+}
+
+func (store *MemoryTokenStore) UseAccountToken(ctx context.Context, hash, purpose string) (*models.AccountToken, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	token, ok := store.accountTokens[hash]
+	if !ok || token.Purpose != purpose || token.Used || !token.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+
+	token.Used = true
+	store.accountTokens[hash] = token
+	return &token, nil
+}
+
+func (store *MemoryTokenStore) DeleteAccountTokens(ctx context.Context, userID primitive.ObjectID, purpose string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for hash, token := range store.accountTokens {
+		if token.UserID == userID && token.Purpose == purpose {
+			delete(store.accountTokens, hash)
+		}
+	}
+	return nil
+}
+
+// removeExpired удаляет просроченные записи, заменяя TTL-индексы MongoDB. Вызывается под блокировкой
+func (store *MemoryTokenStore) removeExpired() {
+	now := time.Now()
+	for hash, token := range store.refreshTokens {
+		if token.ExpiresAt.Before(now) {
+			delete(store.refreshTokens, hash)
+		}
+	}
+	for id, expiresAt := range store.revokedTokens {
+		if expiresAt.Before(now) {
+			delete(store.revokedTokens, id)
+		}
+	}
+	for hash, token := range store.accountTokens {
+		if token.ExpiresAt.Before(now) {
+			delete(store.accountTokens, hash)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryUserStore - потокобезопасная реализация UserStore в оперативной памяти
+type MemoryUserStore struct {
+	mutex sync.RWMutex
+	users map[primitive.ObjectID]models.User
+}
+
+func CreateMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{users: map[primitive.ObjectID]models.User{}}
+}
+
+func (store *MemoryUserStore) GetUser(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	user, ok := store.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &user, nil
+}
+
+func (store *MemoryUserStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, user := range store.users {
+		if user.Username == username {
+			return &user, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (store *MemoryUserStore) FindUsersByEmail(ctx context.Context, email string) ([]models.User, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	users := []models.User{}
+	for _, user := range store.users {
+		if user.Email == email {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (store *MemoryUserStore) CreateUser(ctx context.Context, user *models.User) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, existing := range store.users {
+		if existing.Username == user.Username {
+			return ErrConflict
+		}
+	}
+	user.ID = primitive.NewObjectID()
+	store.users[user.ID] = *user
+	return nil
+}
+
+func (store *MemoryUserStore) SetUserRole(ctx context.Context, id primitive.ObjectID, role string, shelterID primitive.ObjectID) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	user, ok := store.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	user.Role = role
+	user.ShelterID = shelterID
+	store.users[id] = user
+	return nil
+}
+
+func (store *MemoryUserStore) SetEmailVerified(ctx context.Context, id primitive.ObjectID, email string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	user, ok := store.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if user.Email != email {
+		return ErrConflict
+	}
+
+	user.EmailVerified = true
+	store.users[id] = user
+	return nil
+}
+
+func (store *MemoryUserStore) SetPassword(ctx context.Context, id primitive.ObjectID, hash string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	user, ok := store.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	user.Password = hash
+	store.users[id] = user
+	return nil
+}
+
+func (store *MemoryUserStore) FindUsers(ctx context.Context, filter UserFilter, page PageRequest) (*UserPage, error) {
+	page = page.withDefaults()
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	users := []models.User{}
+	for _, user := range store.users {
+		if filter.Matches(&user) {
+			users = append(users, user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if page.Desc {
+			return compareUsers(&users[i], &users[j], page.Sort) > 0
+		}
+		return compareUsers(&users[i], &users[j], page.Sort) < 0
+	})
+
+	result := &UserPage{Total: int64(len(users))}
+
+	start := page.Offset
+	if page.Cursor != "" {
+		last, err := decodeUserCursor(page)
+		if err != nil {
+			return nil, err
+		}
+
+		// Ищем первый элемент, идущий после курсора
+		start = sort.Search(len(users), func(i int) bool {
+			if page.Desc {
+				return compareUsers(&users[i], last, page.Sort) < 0
+			}
+			return compareUsers(&users[i], last, page.Sort) > 0
+		})
+	}
+
+	if start > len(users) {
+		start = len(users)
+	}
+	end := min(start+page.Limit, len(users))
+	result.Users = users[start:end]
+	if end < len(users) && end > start {
+		result.NextCursor = encodeUserCursor(page, &users[end-1])
+	}
+
+	return result, nil
+}
+
+func (store *MemoryUserStore) UpdateProfile(ctx context.Context, id primitive.ObjectID, profile models.UserProfile) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	user, ok := store.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	user.Profile = profile
+	store.users[id] = user
+	return nil
+}
+
+func (store *MemoryUserStore) SetEmail(ctx context.Context, id primitive.ObjectID, email string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	user, ok := store.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	user.Email = email
+	user.EmailVerified = false
+	store.users[id] = user
+	return nil
+}
+
+func (store *MemoryUserStore) SetUserDisabled(ctx context.Context, id primitive.ObjectID, disabled bool) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	user, ok := store.users[id]
+	if !ok || user.DeletedAt != nil {
+		return ErrNotFound
+	}
+
+	user.Disabled = disabled
+	store.users[id] = user
+	return nil
+}
+
+func (store *MemoryUserStore) AnonymizeUser(ctx context.Context, id primitive.ObjectID, deletedAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	user, ok := store.users[id]
+	if !ok || user.DeletedAt != nil {
+		return ErrNotFound
+	}
+
+	store.users[id] = anonymizedUser(user, deletedAt)
+	return nil
+}
@@ -2,24 +2,24 @@ package databases
 
 import (
 	"context"
-	"log"
-	"os"
+	"log/slog"
+	"myproject/config"
 
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 type MongoDB struct {
 	Client *mongo.Client
+	name   string
+	logger *slog.Logger
 }
 
-func Connect() (*MongoDB, error) {
-	uri := os.Getenv("MONGO_URI")
-	if uri == "" {
-		uri = "mongodb://localhost:27017" // Значение по умолчанию
-	}
-
-	clientOptions := options.Client().ApplyURI(uri)
+// Connect подключается к MongoDB. Каждый из monitors получает события о каждой выполненной команде
+func Connect(cfg config.MongoConfig, monitors []*event.CommandMonitor, logger *slog.Logger) (*MongoDB, error) {
+	clientOptions := options.Client().ApplyURI(cfg.URI.Value()).SetMonitor(combineMonitors(monitors))
 	client, err := mongo.Connect(context.TODO(), clientOptions)
 	if err != nil {
 		return nil, err
@@ -31,14 +31,52 @@ func Connect() (*MongoDB, error) {
 		return nil, err
 	}
 
-	log.Println("Connected to MongoDB!")
-	return &MongoDB{Client: client}, nil
+	logger.Info("connected to MongoDB", "database", cfg.Database)
+	return &MongoDB{Client: client, name: cfg.Database, logger: logger}, nil
+}
+
+// combineMonitors объединяет наблюдатели команд, так как драйвер принимает только один.
+// События передаются наблюдателям в порядке их перечисления
+func combineMonitors(monitors []*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, command *event.CommandStartedEvent) {
+			for _, monitor := range monitors {
+				if monitor.Started != nil {
+					monitor.Started(ctx, command)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, command *event.CommandSucceededEvent) {
+			for _, monitor := range monitors {
+				if monitor.Succeeded != nil {
+					monitor.Succeeded(ctx, command)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, command *event.CommandFailedEvent) {
+			for _, monitor := range monitors {
+				if monitor.Failed != nil {
+					monitor.Failed(ctx, command)
+				}
+			}
+		},
+	}
+}
+
+// Disconnect закрывает соединения с MongoDB, дожидаясь завершения выполняющихся операций, но не дольше ctx
+func (database *MongoDB) Disconnect(ctx context.Context) error {
+	if err := database.Client.Disconnect(ctx); err != nil {
+		return err
+	}
+	database.logger.Info("disconnected from MongoDB")
+	return nil
 }
 
-func (database *MongoDB) Disconnect() error {
-	return database.Client.Disconnect(context.TODO())
+// Ping проверяет доступность основного сервера MongoDB
+func (database *MongoDB) Ping(ctx context.Context) error {
+	return database.Client.Ping(ctx, readpref.Primary())
 }
 
 func (database *MongoDB) Collection(name string) *mongo.Collection {
-	return database.Client.Database("testdb").Collection(name)
+	return database.Client.Database(database.name).Collection(name)
 }
@@ -0,0 +1,112 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoApplicationStore - реализация ApplicationStore поверх коллекции "applications"
+type MongoApplicationStore struct {
+	collection *mongo.Collection
+}
+
+func CreateMongoApplicationStore(database *MongoDB) *MongoApplicationStore {
+	return &MongoApplicationStore{collection: database.Collection("applications")}
+}
+
+func (store *MongoApplicationStore) GetApplication(ctx context.Context, id primitive.ObjectID) (*models.Application, error) {
+	var application models.Application
+	err := store.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&application)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &application, nil
+}
+
+func (store *MongoApplicationStore) FindApplications(ctx context.Context, filter ApplicationFilter) ([]models.Application, error) {
+	query := bson.M{}
+	if !filter.PetID.IsZero() {
+		query["pet_id"] = filter.PetID
+	}
+	if !filter.UserID.IsZero() {
+		query["user_id"] = filter.UserID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := store.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	applications := []models.Application{}
+	if err := cursor.All(ctx, &applications); err != nil {
+		return nil, err
+	}
+
+	return applications, nil
+}
+
+func (store *MongoApplicationStore) CreateApplication(ctx context.Context, application *models.Application) error {
+	application.ID = primitive.NewObjectID()
+	_, err := store.collection.InsertOne(ctx, application)
+	return err
+}
+
+func (store *MongoApplicationStore) UpdateApplicationStatus(ctx context.Context, id primitive.ObjectID, from, to, comment string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":     to,
+			"comment":    comment,
+			"updated_at": time.Now(),
+		},
+	}
+
+	// Условие на текущий статус защищает от параллельного рассмотрения одной заявки
+	result, err := store.collection.UpdateOne(ctx, bson.M{"_id": id, "status": from}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		count, err := store.collection.CountDocuments(ctx, bson.M{"_id": id})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrNotFound
+		}
+		return ErrConflict
+	}
+
+	return nil
+}
+
+func (store *MongoApplicationStore) CloseApplicationsForPet(ctx context.Context, petID, except primitive.ObjectID, comment string) error {
+	filter := bson.M{
+		"pet_id": petID,
+		"_id":    bson.M{"$ne": except},
+		"status": bson.M{"$in": bson.A{models.ApplicationSubmitted, models.ApplicationUnderReview}},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.ApplicationRejected,
+			"comment":    comment,
+			"updated_at": time.Now(),
+		},
+	}
+
+	_, err := store.collection.UpdateMany(ctx, filter, update)
+	return err
+}
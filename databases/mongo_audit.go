@@ -0,0 +1,76 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoAuditStore - реализация AuditStore поверх коллекции "audit_log"
+type MongoAuditStore struct {
+	collection *mongo.Collection
+}
+
+func CreateMongoAuditStore(database *MongoDB) *MongoAuditStore {
+	return &MongoAuditStore{collection: database.Collection("audit_log")}
+}
+
+// EnsureIndexes создаёт индексы коллекции
+func (store *MongoAuditStore) EnsureIndexes(ctx context.Context) error {
+	_, err := store.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "entity", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	return err
+}
+
+func (store *MongoAuditStore) RecordAudit(ctx context.Context, entry *models.AuditEntry) error {
+	entry.ID = primitive.NewObjectID()
+	_, err := store.collection.InsertOne(ctx, entry)
+	return err
+}
+
+func (store *MongoAuditStore) FindAudit(ctx context.Context, filter AuditFilter, limit int) ([]models.AuditEntry, error) {
+	query := bson.M{}
+	if !filter.ActorID.IsZero() {
+		query["actor_id"] = filter.ActorID
+	}
+	if filter.Entity != "" {
+		query["entity"] = filter.Entity
+	}
+	if filter.EntityID != "" {
+		query["entity_id"] = filter.EntityID
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		timestamp := bson.M{}
+		if !filter.From.IsZero() {
+			timestamp["$gte"] = filter.From
+		}
+		if !filter.To.IsZero() {
+			timestamp["$lt"] = filter.To
+		}
+		query["timestamp"] = timestamp
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := store.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
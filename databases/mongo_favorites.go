@@ -0,0 +1,128 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoFavoriteStore - реализация FavoriteStore поверх коллекции "favorites"
+type MongoFavoriteStore struct {
+	collection *mongo.Collection
+}
+
+func CreateMongoFavoriteStore(database *MongoDB) *MongoFavoriteStore {
+	return &MongoFavoriteStore{collection: database.Collection("favorites")}
+}
+
+// EnsureIndexes создаёт индексы коллекции. Уникальный индекс не даёт добавить животное в избранное дважды
+func (store *MongoFavoriteStore) EnsureIndexes(ctx context.Context) error {
+	_, err := store.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "pet_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "pet_id", Value: 1}}},
+	})
+	return err
+}
+
+func (store *MongoFavoriteStore) AddFavorite(ctx context.Context, favorite *models.Favorite) error {
+	filter := bson.M{"user_id": favorite.UserID, "pet_id": favorite.PetID}
+	update := bson.M{"$setOnInsert": bson.M{"created_at": favorite.CreatedAt}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	err := store.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(favorite)
+	if mongo.IsDuplicateKeyError(err) {
+		// Параллельный запрос уже добавил эту запись
+		return nil
+	}
+	return err
+}
+
+func (store *MongoFavoriteStore) RemoveFavorite(ctx context.Context, userID, petID primitive.ObjectID) error {
+	result, err := store.collection.DeleteOne(ctx, bson.M{"user_id": userID, "pet_id": petID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoFavoriteStore) FindFavorites(ctx context.Context, userID primitive.ObjectID) ([]models.Favorite, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := store.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	favorites := []models.Favorite{}
+	if err := cursor.All(ctx, &favorites); err != nil {
+		return nil, err
+	}
+
+	return favorites, nil
+}
+
+func (store *MongoFavoriteStore) CountFavorites(ctx context.Context, petIDs []primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"pet_id": bson.M{"$in": petIDs}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$pet_id", "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := store.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		PetID primitive.ObjectID `bson:"_id"`
+		Count int                `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	counts := map[primitive.ObjectID]int{}
+	for _, result := range results {
+		counts[result.PetID] = result.Count
+	}
+
+	return counts, nil
+}
+
+func (store *MongoFavoriteStore) FavoritePetIDs(ctx context.Context, userID primitive.ObjectID, petIDs []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	filter := bson.M{"user_id": userID, "pet_id": bson.M{"$in": petIDs}}
+	cursor, err := store.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"pet_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var favorites []models.Favorite
+	if err := cursor.All(ctx, &favorites); err != nil {
+		return nil, err
+	}
+
+	favorited := map[primitive.ObjectID]bool{}
+	for _, favorite := range favorites {
+		favorited[favorite.PetID] = true
+	}
+
+	return favorited, nil
+}
+
+func (store *MongoFavoriteStore) DeleteFavoritesForPet(ctx context.Context, petID primitive.ObjectID) error {
+	_, err := store.collection.DeleteMany(ctx, bson.M{"pet_id": petID})
+	return err
+}
+
+func (store *MongoFavoriteStore) DeleteFavoritesForUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := store.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
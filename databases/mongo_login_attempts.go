@@ -0,0 +1,78 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoLoginAttemptStore - реализация LoginAttemptStore поверх коллекции "login_attempts".
+// Имя пользователя служит идентификатором документа
+type MongoLoginAttemptStore struct {
+	collection *mongo.Collection
+}
+
+func CreateMongoLoginAttemptStore(database *MongoDB) *MongoLoginAttemptStore {
+	return &MongoLoginAttemptStore{collection: database.Collection("login_attempts")}
+}
+
+// EnsureIndexes создаёт TTL-индекс, удаляющий истёкшие счётчики попыток
+func (store *MongoLoginAttemptStore) EnsureIndexes(ctx context.Context) error {
+	_, err := store.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (store *MongoLoginAttemptStore) GetLoginAttempts(ctx context.Context, username string) (*models.LoginAttempts, error) {
+	// TTL-индекс удаляет документы с задержкой, поэтому истёкшие записи отбрасываются условием
+	filter := bson.M{"_id": username, "expires_at": bson.M{"$gt": time.Now()}}
+	var attempts models.LoginAttempts
+	err := store.collection.FindOne(ctx, filter).Decode(&attempts)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &attempts, nil
+}
+
+func (store *MongoLoginAttemptStore) RecordLoginFailure(ctx context.Context, username string, expiresAt time.Time) (*models.LoginAttempts, error) {
+	// Обновление конвейером атомарно увеличивает счётчик, а для истёкшей или новой записи начинает его с единицы
+	active := bson.M{"$gt": bson.A{"$expires_at", time.Now()}}
+	update := bson.A{bson.M{"$set": bson.M{
+		"failures":     bson.M{"$cond": bson.A{active, bson.M{"$add": bson.A{"$failures", 1}}, 1}},
+		"locked_until": bson.M{"$cond": bson.A{active, "$locked_until", time.Time{}}},
+		"expires_at":   expiresAt,
+	}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var attempts models.LoginAttempts
+	if err := store.collection.FindOneAndUpdate(ctx, bson.M{"_id": username}, update, opts).Decode(&attempts); err != nil {
+		return nil, err
+	}
+	return &attempts, nil
+}
+
+func (store *MongoLoginAttemptStore) LockLogin(ctx context.Context, username string, until time.Time) error {
+	result, err := store.collection.UpdateOne(ctx, bson.M{"_id": username}, bson.M{"$set": bson.M{"locked_until": until}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoLoginAttemptStore) ResetLoginAttempts(ctx context.Context, username string) error {
+	_, err := store.collection.DeleteOne(ctx, bson.M{"_id": username})
+	return err
+}
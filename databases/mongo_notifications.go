@@ -0,0 +1,67 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoNotificationStore - реализация NotificationStore поверх коллекции "notifications"
+type MongoNotificationStore struct {
+	collection *mongo.Collection
+}
+
+func CreateMongoNotificationStore(database *MongoDB) *MongoNotificationStore {
+	return &MongoNotificationStore{collection: database.Collection("notifications")}
+}
+
+// EnsureIndexes создаёт индексы коллекции
+func (store *MongoNotificationStore) EnsureIndexes(ctx context.Context) error {
+	_, err := store.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	return err
+}
+
+func (store *MongoNotificationStore) CreateNotification(ctx context.Context, notification *models.Notification) error {
+	notification.ID = primitive.NewObjectID()
+	_, err := store.collection.InsertOne(ctx, notification)
+	return err
+}
+
+func (store *MongoNotificationStore) FindNotifications(ctx context.Context, userID primitive.ObjectID) ([]models.Notification, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := store.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (store *MongoNotificationStore) MarkNotificationRead(ctx context.Context, userID, id primitive.ObjectID) error {
+	result, err := store.collection.UpdateOne(ctx, bson.M{"_id": id, "user_id": userID}, bson.M{"$set": bson.M{"read": true}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoNotificationStore) DeleteNotificationsForUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := store.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,400 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoPetStore - реализация PetStore поверх коллекций "pets" и "pet_status_history"
+type MongoPetStore struct {
+	collection *mongo.Collection
+	history    *mongo.Collection
+}
+
+func CreateMongoPetStore(database *MongoDB) *MongoPetStore {
+	return &MongoPetStore{collection: database.Collection("pets"), history: database.Collection("pet_status_history")}
+}
+
+// EnsureIndexes создаёт индексы коллекции, в том числе текстовый индекс для полнотекстового поиска
+func (store *MongoPetStore) EnsureIndexes(ctx context.Context) error {
+	_, err := store.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "name", Value: "text"}, {Key: "breed", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().
+				SetName("pets_text").
+				SetDefaultLanguage("russian").
+				SetWeights(bson.D{{Key: "name", Value: 10}, {Key: "breed", Value: 5}, {Key: "description", Value: 1}}),
+		},
+		{Keys: bson.D{{Key: "species", Value: 1}, {Key: "breed", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "deleted_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = store.history.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "pet_id", Value: 1}, {Key: "changed_at", Value: 1}},
+	})
+	return err
+}
+
+// MigrateStatuses заполняет статус животных, созданных до появления статусов, по устаревшему флагу adopted
+func (store *MongoPetStore) MigrateStatuses(ctx context.Context) error {
+	missing := bson.M{"status": bson.M{"$exists": false}}
+
+	_, err := store.collection.UpdateMany(ctx, bson.M{"$and": bson.A{missing, bson.M{"adopted": true}}},
+		bson.M{"$set": bson.M{"status": models.PetAdopted}, "$unset": bson.M{"adopted": ""}})
+	if err != nil {
+		return err
+	}
+
+	_, err = store.collection.UpdateMany(ctx, missing,
+		bson.M{"$set": bson.M{"status": models.PetAvailable}, "$unset": bson.M{"adopted": ""}})
+	return err
+}
+
+func (store *MongoPetStore) GetPet(ctx context.Context, id primitive.ObjectID) (*models.Pet, error) {
+	var pet models.Pet
+	err := store.collection.FindOne(ctx, activePet(id)).Decode(&pet)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &pet, nil
+}
+
+func (store *MongoPetStore) GetPets(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]models.Pet, error) {
+	pets := map[primitive.ObjectID]models.Pet{}
+	if len(ids) == 0 {
+		return pets, nil
+	}
+
+	cursor, err := store.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "deleted_at": bson.M{"$exists": false}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var pet models.Pet
+		if err := cursor.Decode(&pet); err != nil {
+			return nil, err
+		}
+		pets[pet.ID] = pet
+	}
+	return pets, cursor.Err()
+}
+
+func (store *MongoPetStore) FindPets(ctx context.Context, filter PetFilter, page PageRequest) (*PetPage, error) {
+	page = page.withDefaults()
+	query := petQuery(filter)
+
+	total, err := store.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	order := 1
+	comparison := "$gt"
+	if page.Desc {
+		order = -1
+		comparison = "$lt"
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: page.Sort, Value: order}, {Key: "_id", Value: order}}).
+		SetLimit(int64(page.Limit + 1))
+
+	if page.Cursor != "" {
+		// Продолжаем выдачу после последнего элемента предыдущей страницы
+		value, id, err := decodePetCursor(page)
+		if err != nil {
+			return nil, err
+		}
+		query = bson.M{"$and": bson.A{query, bson.M{"$or": bson.A{
+			bson.M{page.Sort: bson.M{comparison: value}},
+			bson.M{page.Sort: value, "_id": bson.M{comparison: id}},
+		}}}}
+	} else if page.Offset > 0 {
+		opts.SetSkip(int64(page.Offset))
+	}
+
+	cursor, err := store.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pets := []models.Pet{}
+	if err := cursor.All(ctx, &pets); err != nil {
+		return nil, err
+	}
+
+	// Запрашивается на один элемент больше, чтобы узнать, есть ли следующая страница
+	result := &PetPage{Pets: pets, Total: total}
+	if len(pets) > page.Limit {
+		result.Pets = pets[:page.Limit]
+		result.NextCursor = encodePetCursor(page, &result.Pets[page.Limit-1])
+	}
+
+	return result, nil
+}
+
+// petQuery строит запрос MongoDB на основе параметров поиска
+func petQuery(filter PetFilter) bson.M {
+	query := bson.M{"deleted_at": bson.M{"$exists": false}}
+	if !filter.ShelterID.IsZero() {
+		query["shelter_id"] = filter.ShelterID
+	}
+	if filter.NamePrefix != "" {
+		query["name"] = bson.M{"$regex": "^" + regexp.QuoteMeta(filter.NamePrefix), "$options": "i"}
+	}
+	if filter.AgeMin != nil || filter.AgeMax != nil {
+		age := bson.M{}
+		if filter.AgeMin != nil {
+			age["$gte"] = *filter.AgeMin
+		}
+		if filter.AgeMax != nil {
+			age["$lte"] = *filter.AgeMax
+		}
+		query["age"] = age
+	}
+	if filter.Gender != "" {
+		query["gender"] = filter.Gender
+	}
+	if len(filter.Species) > 0 {
+		query["species"] = bson.M{"$in": filter.Species}
+	}
+	if len(filter.Breeds) > 0 {
+		query["breed"] = bson.M{"$in": filter.Breeds}
+	}
+	if len(filter.Statuses) > 0 {
+		query["status"] = bson.M{"$in": filter.Statuses}
+	}
+	if filter.Text != "" {
+		query["$text"] = bson.M{"$search": filter.Text}
+	}
+	return query
+}
+
+func (store *MongoPetStore) CreatePet(ctx context.Context, pet *models.Pet) error {
+	pet.ID = primitive.NewObjectID()
+	_, err := store.collection.InsertOne(ctx, pet)
+	return err
+}
+
+func (store *MongoPetStore) UpdatePet(ctx context.Context, id primitive.ObjectID, pet *models.Pet) error {
+	update := bson.M{
+		"$set": bson.M{
+			"shelter_id":  pet.ShelterID,
+			"name":        pet.Name,
+			"age":         pet.Age,
+			"gender":      pet.Gender,
+			"species":     pet.Species,
+			"breed":       pet.Breed,
+			"description": pet.Description,
+		},
+	}
+
+	result, err := store.collection.UpdateOne(ctx, activePet(id), update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoPetStore) DeletePet(ctx context.Context, id primitive.ObjectID, deletedBy primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{"deleted_at": time.Now(), "deleted_by": deletedBy}}
+	result, err := store.collection.UpdateOne(ctx, activePet(id), update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoPetStore) GetDeletedPet(ctx context.Context, id primitive.ObjectID) (*models.Pet, error) {
+	var pet models.Pet
+	err := store.collection.FindOne(ctx, deletedPet(id)).Decode(&pet)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &pet, nil
+}
+
+func (store *MongoPetStore) FindDeletedPets(ctx context.Context, shelterID primitive.ObjectID) ([]models.Pet, error) {
+	query := bson.M{"deleted_at": bson.M{"$exists": true}}
+	if !shelterID.IsZero() {
+		query["shelter_id"] = shelterID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+	cursor, err := store.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pets := []models.Pet{}
+	if err := cursor.All(ctx, &pets); err != nil {
+		return nil, err
+	}
+
+	return pets, nil
+}
+
+func (store *MongoPetStore) RestorePet(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{"$unset": bson.M{"deleted_at": "", "deleted_by": ""}}
+	result, err := store.collection.UpdateOne(ctx, deletedPet(id), update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoPetStore) PurgeDeletedPets(ctx context.Context, deletedBefore time.Time) ([]models.Pet, error) {
+	expired := bson.M{"deleted_at": bson.M{"$lt": deletedBefore}}
+	cursor, err := store.collection.Find(ctx, expired)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var candidates []models.Pet
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	// Удаляем по одному с повторной проверкой условия, чтобы не удалить животное, восстановленное в это время
+	purged := []models.Pet{}
+	for _, pet := range candidates {
+		result, err := store.collection.DeleteOne(ctx, bson.M{"_id": pet.ID, "deleted_at": bson.M{"$lt": deletedBefore}})
+		if err != nil {
+			return purged, err
+		}
+		if result.DeletedCount > 0 {
+			purged = append(purged, pet)
+		}
+	}
+
+	return purged, nil
+}
+
+// activePet - условие поиска животного по ID, не находящегося в корзине
+func activePet(id primitive.ObjectID) bson.M {
+	return bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}
+}
+
+// deletedPet - условие поиска животного по ID, находящегося в корзине
+func deletedPet(id primitive.ObjectID) bson.M {
+	return bson.M{"_id": id, "deleted_at": bson.M{"$exists": true}}
+}
+
+func (store *MongoPetStore) SetPetStatus(ctx context.Context, change *models.PetStatusChange) error {
+	// Условие на текущий статус делает переход атомарным: из двух параллельных переходов пройдёт только один
+	result, err := store.collection.UpdateOne(ctx,
+		bson.M{"_id": change.PetID, "status": change.From, "deleted_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"status": change.To}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		count, err := store.collection.CountDocuments(ctx, activePet(change.PetID))
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrNotFound
+		}
+		return ErrConflict
+	}
+
+	return store.AddPetStatusChange(ctx, change)
+}
+
+func (store *MongoPetStore) AddPetStatusChange(ctx context.Context, change *models.PetStatusChange) error {
+	change.ID = primitive.NewObjectID()
+	_, err := store.history.InsertOne(ctx, change)
+	return err
+}
+
+func (store *MongoPetStore) FindPetStatusChanges(ctx context.Context, petID primitive.ObjectID) ([]models.PetStatusChange, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "changed_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := store.history.Find(ctx, bson.M{"pet_id": petID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	changes := []models.PetStatusChange{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (store *MongoPetStore) AddPetPhoto(ctx context.Context, id primitive.ObjectID, photo *models.Photo) error {
+	result, err := store.collection.UpdateOne(ctx, activePet(id), bson.M{"$push": bson.M{"photos": photo}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoPetStore) SetPetPhotos(ctx context.Context, id primitive.ObjectID, current, photos []models.Photo) error {
+	// Условие на текущий список делает замену атомарной: параллельно загруженная или удалённая фотография не теряется.
+	// У животного без фотографий поля photos может не быть вовсе
+	filter := activePet(id)
+	if len(current) == 0 {
+		filter["photos"] = bson.M{"$in": bson.A{nil, bson.A{}}}
+	} else {
+		filter["photos"] = current
+	}
+
+	result, err := store.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"photos": photos}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		count, err := store.collection.CountDocuments(ctx, activePet(id))
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrNotFound
+		}
+		return ErrConflict
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRoleStore - реализация RoleStore поверх коллекции "roles"
+type MongoRoleStore struct {
+	collection *mongo.Collection
+}
+
+func CreateMongoRoleStore(database *MongoDB) *MongoRoleStore {
+	return &MongoRoleStore{collection: database.Collection("roles")}
+}
+
+func (store *MongoRoleStore) GetRole(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	err := store.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&role)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (store *MongoRoleStore) FindRoles(ctx context.Context) ([]models.Role, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := store.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	roles := []models.Role{}
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (store *MongoRoleStore) SaveRole(ctx context.Context, role *models.Role) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := store.collection.ReplaceOne(ctx, bson.M{"_id": role.Name}, role, opts)
+	return err
+}
+
+func (store *MongoRoleStore) DeleteRole(ctx context.Context, name string) error {
+	result, err := store.collection.DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
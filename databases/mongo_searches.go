@@ -0,0 +1,120 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoSearchStore - реализация SearchStore поверх коллекций "saved_searches" и "search_matches"
+type MongoSearchStore struct {
+	searches *mongo.Collection
+	matches  *mongo.Collection
+}
+
+func CreateMongoSearchStore(database *MongoDB) *MongoSearchStore {
+	return &MongoSearchStore{
+		searches: database.Collection("saved_searches"),
+		matches:  database.Collection("search_matches"),
+	}
+}
+
+// EnsureIndexes создаёт индексы коллекций. Уникальный индекс не даёт записать животное для поиска дважды
+func (store *MongoSearchStore) EnsureIndexes(ctx context.Context) error {
+	_, err := store.searches.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}}})
+	if err != nil {
+		return err
+	}
+
+	_, err = store.matches.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "search_id", Value: 1}, {Key: "pet_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "notified", Value: 1}}},
+	})
+	return err
+}
+
+func (store *MongoSearchStore) CreateSavedSearch(ctx context.Context, search *models.SavedSearch) error {
+	search.ID = primitive.NewObjectID()
+	_, err := store.searches.InsertOne(ctx, search)
+	return err
+}
+
+func (store *MongoSearchStore) GetSavedSearch(ctx context.Context, id primitive.ObjectID) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	err := store.searches.FindOne(ctx, bson.M{"_id": id}).Decode(&search)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &search, nil
+}
+
+func (store *MongoSearchStore) FindSavedSearches(ctx context.Context, userID primitive.ObjectID) ([]models.SavedSearch, error) {
+	filter := bson.M{}
+	if !userID.IsZero() {
+		filter["user_id"] = userID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := store.searches.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	searches := []models.SavedSearch{}
+	if err := cursor.All(ctx, &searches); err != nil {
+		return nil, err
+	}
+
+	return searches, nil
+}
+
+func (store *MongoSearchStore) DeleteSavedSearch(ctx context.Context, id primitive.ObjectID) error {
+	result, err := store.searches.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	_, err = store.matches.DeleteMany(ctx, bson.M{"search_id": id})
+	return err
+}
+
+func (store *MongoSearchStore) AddSearchMatch(ctx context.Context, match *models.SearchMatch) error {
+	match.ID = primitive.NewObjectID()
+	_, err := store.matches.InsertOne(ctx, match)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (store *MongoSearchStore) FindPendingMatches(ctx context.Context) ([]models.SearchMatch, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := store.matches.Find(ctx, bson.M{"notified": false}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	matches := []models.SearchMatch{}
+	if err := cursor.All(ctx, &matches); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+func (store *MongoSearchStore) MarkMatchesNotified(ctx context.Context, ids []primitive.ObjectID) error {
+	_, err := store.matches.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"notified": true}})
+	return err
+}
@@ -0,0 +1,89 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoShelterStore - реализация ShelterStore поверх коллекции "shelters"
+type MongoShelterStore struct {
+	collection *mongo.Collection
+}
+
+func CreateMongoShelterStore(database *MongoDB) *MongoShelterStore {
+	return &MongoShelterStore{collection: database.Collection("shelters")}
+}
+
+func (store *MongoShelterStore) GetShelter(ctx context.Context, id primitive.ObjectID) (*models.Shelter, error) {
+	var shelter models.Shelter
+	err := store.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&shelter)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &shelter, nil
+}
+
+func (store *MongoShelterStore) FindShelters(ctx context.Context) ([]models.Shelter, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := store.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	shelters := []models.Shelter{}
+	if err := cursor.All(ctx, &shelters); err != nil {
+		return nil, err
+	}
+
+	return shelters, nil
+}
+
+func (store *MongoShelterStore) CreateShelter(ctx context.Context, shelter *models.Shelter) error {
+	shelter.ID = primitive.NewObjectID()
+	_, err := store.collection.InsertOne(ctx, shelter)
+	return err
+}
+
+func (store *MongoShelterStore) UpdateShelter(ctx context.Context, id primitive.ObjectID, shelter *models.Shelter) error {
+	update := bson.M{
+		"$set": bson.M{
+			"name":    shelter.Name,
+			"address": shelter.Address,
+			"phone":   shelter.Phone,
+			"email":   shelter.Email,
+			"website": shelter.Website,
+			"hours":   shelter.Hours,
+		},
+	}
+
+	result, err := store.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoShelterStore) DeleteShelter(ctx context.Context, id primitive.ObjectID) error {
+	result, err := store.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoTokenStore - реализация TokenStore поверх коллекций "refresh_tokens", "revoked_tokens" и "account_tokens"
+type MongoTokenStore struct {
+	refreshTokens *mongo.Collection
+	revokedTokens *mongo.Collection
+	accountTokens *mongo.Collection
+}
+
+func CreateMongoTokenStore(database *MongoDB) *MongoTokenStore {
+	return &MongoTokenStore{
+		refreshTokens: database.Collection("refresh_tokens"),
+		revokedTokens: database.Collection("revoked_tokens"),
+		accountTokens: database.Collection("account_tokens"),
+	}
+}
+
+// EnsureIndexes создаёт индексы коллекций. TTL-индексы удаляют записи после истечения срока действия токенов
+func (store *MongoTokenStore) EnsureIndexes(ctx context.Context) error {
+	_, err := store.refreshTokens.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "family_id", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = store.revokedTokens.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = store.accountTokens.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+func (store *MongoTokenStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	token.ID = primitive.NewObjectID()
+	_, err := store.refreshTokens.InsertOne(ctx, token)
+	return err
+}
+
+func (store *MongoTokenStore) GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := store.refreshTokens.FindOne(ctx, bson.M{"hash": hash}).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (store *MongoTokenStore) MarkRefreshTokenUsed(ctx context.Context, id primitive.ObjectID) error {
+	// Условие на флаги делает пометку атомарной: из двух параллельных обновлений пройдёт только одно
+	filter := bson.M{"_id": id, "used": false, "revoked": false}
+	result, err := store.refreshTokens.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"used": true}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+
+	return nil
+}
+
+func (store *MongoTokenStore) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	_, err := store.refreshTokens.UpdateMany(ctx, bson.M{"family_id": familyID}, bson.M{"$set": bson.M{"revoked": true}})
+	return err
+}
+
+func (store *MongoTokenStore) RevokeUserTokens(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	// Семейства читаются до отзыва: после него уже не отличить токены, отозванные этим вызовом
+	familyIDs, err := store.refreshTokens.Distinct(ctx, "family_id", bson.M{"user_id": userID, "expires_at": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := store.refreshTokens.UpdateMany(ctx, bson.M{"user_id": userID}, bson.M{"$set": bson.M{"revoked": true}}); err != nil {
+		return nil, err
+	}
+
+	families := make([]string, 0, len(familyIDs))
+	for _, familyID := range familyIDs {
+		if familyID, ok := familyID.(string); ok {
+			families = append(families, familyID)
+		}
+	}
+	return families, nil
+}
+
+func (store *MongoTokenStore) RevokeToken(ctx context.Context, id string, expiresAt time.Time) error {
+	opts := options.Update().SetUpsert(true)
+	_, err := store.revokedTokens.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$max": bson.M{"expires_at": expiresAt}}, opts)
+	return err
+}
+
+func (store *MongoTokenStore) IsTokenRevoked(ctx context.Context, ids ...string) (bool, error) {
+	filter := bson.M{"_id": bson.M{"$in": ids}, "expires_at": bson.M{"$gt": time.Now()}}
+	count, err := store.revokedTokens.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (store *MongoTokenStore) CreateAccountToken(ctx context.Context, token *models.AccountToken) error {
+	token.ID = primitive.NewObjectID()
+	_, err := store.accountTokens.InsertOne(ctx, token)
+	return err
+}
+
+func (store *MongoTokenStore) GetAccountToken(ctx context.Context, hash, purpose string) (*models.AccountToken, error) {
+	filter := bson.M{"hash": hash, "purpose": purpose, "used": false, "expires_at": bson.M{"$gt": time.Now()}}
+	var token models.AccountToken
+	err := store.accountTokens.FindOne(ctx, filter).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (store *MongoTokenStore) UseAccountToken(ctx context.Context, hash, purpose string) (*models.AccountToken, error) {
+	// Условие на флаг делает токен одноразовым: из двух параллельных запросов пройдёт только один
+	filter := bson.M{"hash": hash, "purpose": purpose, "used": false, "expires_at": bson.M{"$gt": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var token models.AccountToken
+	err := store.accountTokens.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"used": true}}, opts).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (store *MongoTokenStore) DeleteAccountTokens(ctx context.Context, userID primitive.ObjectID, purpose string) error {
+	_, err := store.accountTokens.DeleteMany(ctx, bson.M{"user_id": userID, "purpose": purpose})
+	return err
+}
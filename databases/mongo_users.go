@@ -0,0 +1,264 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoUserStore - реализация UserStore поверх коллекции "users"
+type MongoUserStore struct {
+	collection *mongo.Collection
+}
+
+func CreateMongoUserStore(database *MongoDB) *MongoUserStore {
+	return &MongoUserStore{collection: database.Collection("users")}
+}
+
+// EnsureIndexes создаёт уникальный индекс по имени пользователя, индекс по адресу почты
+// и индекс по дате регистрации для сортировки списка пользователей
+func (store *MongoUserStore) EnsureIndexes(ctx context.Context) error {
+	_, err := store.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "email", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	return err
+}
+
+func (store *MongoUserStore) GetUser(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	var user models.User
+	err := store.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (store *MongoUserStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	err := store.collection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (store *MongoUserStore) FindUsersByEmail(ctx context.Context, email string) ([]models.User, error) {
+	cursor, err := store.collection.Find(ctx, bson.M{"email": email})
+	if err != nil {
+		return nil, err
+	}
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (store *MongoUserStore) CreateUser(ctx context.Context, user *models.User) error {
+	user.ID = primitive.NewObjectID()
+	_, err := store.collection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (store *MongoUserStore) SetUserRole(ctx context.Context, id primitive.ObjectID, role string, shelterID primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{"role": role, "shelter_id": shelterID}}
+	if shelterID.IsZero() {
+		update = bson.M{"$set": bson.M{"role": role}, "$unset": bson.M{"shelter_id": ""}}
+	}
+
+	result, err := store.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoUserStore) SetEmailVerified(ctx context.Context, id primitive.ObjectID, email string) error {
+	// Условие на адрес не даёт подтвердить адрес, заменённый после отправки письма
+	result, err := store.collection.UpdateOne(ctx, bson.M{"_id": id, "email": email}, bson.M{"$set": bson.M{"email_verified": true}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+
+	return nil
+}
+
+func (store *MongoUserStore) SetPassword(ctx context.Context, id primitive.ObjectID, hash string) error {
+	result, err := store.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"password": hash}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoUserStore) FindUsers(ctx context.Context, filter UserFilter, page PageRequest) (*UserPage, error) {
+	page = page.withDefaults()
+	query := userQuery(filter)
+
+	total, err := store.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	order := 1
+	comparison := "$gt"
+	if page.Desc {
+		order = -1
+		comparison = "$lt"
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: page.Sort, Value: order}, {Key: "_id", Value: order}}).
+		SetLimit(int64(page.Limit + 1))
+
+	if page.Cursor != "" {
+		// Продолжаем выдачу после последнего элемента предыдущей страницы
+		last, err := decodeUserCursor(page)
+		if err != nil {
+			return nil, err
+		}
+		value := userSortValue(last, page.Sort)
+		query = bson.M{"$and": bson.A{query, bson.M{"$or": bson.A{
+			bson.M{page.Sort: bson.M{comparison: value}},
+			bson.M{page.Sort: value, "_id": bson.M{comparison: last.ID}},
+		}}}}
+	} else if page.Offset > 0 {
+		opts.SetSkip(int64(page.Offset))
+	}
+
+	cursor, err := store.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	// Запрашивается на один элемент больше, чтобы узнать, есть ли следующая страница
+	result := &UserPage{Users: users, Total: total}
+	if len(users) > page.Limit {
+		result.Users = users[:page.Limit]
+		result.NextCursor = encodeUserCursor(page, &result.Users[page.Limit-1])
+	}
+
+	return result, nil
+}
+
+// userQuery строит запрос MongoDB на основе параметров поиска пользователей
+func userQuery(filter UserFilter) bson.M {
+	query := bson.M{"deleted_at": bson.M{"$exists": false}}
+	if filter.Query != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(filter.Query), "$options": "i"}
+		query["$or"] = bson.A{bson.M{"username": pattern}, bson.M{"email": pattern}, bson.M{"profile.display_name": pattern}}
+	}
+	if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+	if filter.Disabled != nil && *filter.Disabled {
+		query["disabled"] = true
+	} else if filter.Disabled != nil {
+		// У пользователей, созданных до появления отключения аккаунтов, поля disabled нет
+		query["disabled"] = bson.M{"$ne": true}
+	}
+	return query
+}
+
+func (store *MongoUserStore) UpdateProfile(ctx context.Context, id primitive.ObjectID, profile models.UserProfile) error {
+	result, err := store.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"profile": profile}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoUserStore) SetEmail(ctx context.Context, id primitive.ObjectID, email string) error {
+	update := bson.M{"$set": bson.M{"email": email, "email_verified": false}}
+	if email == "" {
+		update = bson.M{"$set": bson.M{"email_verified": false}, "$unset": bson.M{"email": ""}}
+	}
+
+	result, err := store.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoUserStore) SetUserDisabled(ctx context.Context, id primitive.ObjectID, disabled bool) error {
+	result, err := store.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"disabled": disabled}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (store *MongoUserStore) AnonymizeUser(ctx context.Context, id primitive.ObjectID, deletedAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"username":       anonymizedUsername(id),
+			"password":       "",
+			"email_verified": false,
+			"role":           models.RoleUser,
+			"profile":        models.UserProfile{},
+			"disabled":       true,
+			"deleted_at":     deletedAt,
+		},
+		"$unset": bson.M{"email": "", "shelter_id": ""},
+	}
+
+	result, err := store.collection.UpdateOne(ctx, bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package databases
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"myproject/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCursor возвращается, если курсор страницы повреждён или не соответствует сортировке
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Поля, по которым можно сортировать список домашних животных
+const (
+	PetSortName    = "name"
+	PetSortAge     = "age"
+	PetSortCreated = "created_at"
+)
+
+// DefaultPageLimit - размер страницы, если он не задан
+const DefaultPageLimit = 20
+
+// PageRequest - параметры постраничной выдачи. Если задан Cursor, выдача продолжается
+// после элемента, на котором закончилась предыдущая страница, и Offset не используется
+type PageRequest struct {
+	Sort   string
+	Desc   bool
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// withDefaults подставляет сортировку и размер страницы по умолчанию
+func (page PageRequest) withDefaults() PageRequest {
+	if page.Sort == "" {
+		page.Sort = PetSortCreated
+	}
+	if page.Limit <= 0 {
+		page.Limit = DefaultPageLimit
+	}
+	return page
+}
+
+// PetPage - страница списка домашних животных
+type PetPage struct {
+	Pets  []models.Pet
+	Total int64
+	// NextCursor - курсор следующей страницы, пустой на последней странице
+	NextCursor string
+}
+
+// pageCursor - содержимое курсора: значение поля сортировки и ID последнего элемента страницы
+type pageCursor struct {
+	Sort  string          `json:"s"`
+	Desc  bool            `json:"d"`
+	Value json.RawMessage `json:"v"`
+	ID    string          `json:"id"`
+}
+
+// IsPetSort проверяет, поддерживается ли сортировка по полю sort
+func IsPetSort(sort string) bool {
+	return sort == PetSortName || sort == PetSortAge || sort == PetSortCreated
+}
+
+// petSortValue возвращает значение поля сортировки домашнего животного
+func petSortValue(pet *models.Pet, sort string) interface{} {
+	switch sort {
+	case PetSortName:
+		return pet.Name
+	case PetSortAge:
+		return pet.Age
+	default:
+		return pet.CreatedAt
+	}
+}
+
+// encodePetCursor формирует курсор, указывающий на pet
+func encodePetCursor(page PageRequest, pet *models.Pet) string {
+	value, _ := json.Marshal(petSortValue(pet, page.Sort))
+	data, _ := json.Marshal(pageCursor{Sort: page.Sort, Desc: page.Desc, Value: value, ID: pet.ID.Hex()})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodePageCursor разбирает курсор страницы и проверяет, что он соответствует сортировке page
+func decodePageCursor(page PageRequest) (*pageCursor, primitive.ObjectID, error) {
+	data, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+	if err != nil {
+		return nil, primitive.NilObjectID, ErrInvalidCursor
+	}
+
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, primitive.NilObjectID, ErrInvalidCursor
+	}
+	if cursor.Sort != page.Sort || cursor.Desc != page.Desc {
+		return nil, primitive.NilObjectID, ErrInvalidCursor
+	}
+
+	id, err := primitive.ObjectIDFromHex(cursor.ID)
+	if err != nil {
+		return nil, primitive.NilObjectID, ErrInvalidCursor
+	}
+	return &cursor, id, nil
+}
+
+// decodePetCursor разбирает курсор и возвращает значение поля сортировки и ID элемента
+func decodePetCursor(page PageRequest) (interface{}, primitive.ObjectID, error) {
+	cursor, id, err := decodePageCursor(page)
+	if err != nil {
+		return nil, primitive.NilObjectID, err
+	}
+
+	var value interface{}
+	switch page.Sort {
+	case PetSortName:
+		var name string
+		err = json.Unmarshal(cursor.Value, &name)
+		value = name
+	case PetSortAge:
+		var age int
+		err = json.Unmarshal(cursor.Value, &age)
+		value = age
+	default:
+		var created time.Time
+		err = json.Unmarshal(cursor.Value, &created)
+		value = created
+	}
+	if err != nil {
+		return nil, primitive.NilObjectID, ErrInvalidCursor
+	}
+
+	return value, id, nil
+}
+
+// comparePets сравнивает животных по полю сортировки, а при равенстве - по ID
+func comparePets(a, b *models.Pet, sort string) int {
+	var result int
+	switch sort {
+	case PetSortName:
+		result = strings.Compare(a.Name, b.Name)
+	case PetSortAge:
+		result = a.Age - b.Age
+	default:
+		result = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if result != 0 {
+		return result
+	}
+	return strings.Compare(a.ID.Hex(), b.ID.Hex())
+}
@@ -0,0 +1,197 @@
+package databases
+
+import (
+	"errors"
+	"myproject/models"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PetFilter - параметры поиска домашних животных. Пустые поля не участвуют в фильтрации
+type PetFilter struct {
+	ShelterID primitive.ObjectID
+	// NamePrefix - начало имени, сравнивается без учёта регистра
+	NamePrefix string
+	AgeMin     *int
+	AgeMax     *int
+	Gender     string
+	// Species и Breeds - допустимые значения, животное подходит при совпадении с любым из них
+	Species []string
+	Breeds  []string
+	// Text - строка полнотекстового поиска по имени, породе и описанию
+	Text string
+	// Statuses - допустимые статусы животного
+	Statuses []string
+}
+
+// Matches проверяет, подходит ли домашнее животное под фильтр. Используется хранилищем в памяти
+// и при проверке сохранённых поисков
+func (filter PetFilter) Matches(pet *models.Pet) bool {
+	if !filter.ShelterID.IsZero() && pet.ShelterID != filter.ShelterID {
+		return false
+	}
+	if filter.NamePrefix != "" && !strings.HasPrefix(strings.ToLower(pet.Name), strings.ToLower(filter.NamePrefix)) {
+		return false
+	}
+	if filter.AgeMin != nil && pet.Age < *filter.AgeMin {
+		return false
+	}
+	if filter.AgeMax != nil && pet.Age > *filter.AgeMax {
+		return false
+	}
+	if filter.Gender != "" && pet.Gender != filter.Gender {
+		return false
+	}
+	if len(filter.Species) > 0 && !slices.Contains(filter.Species, pet.Species) {
+		return false
+	}
+	if len(filter.Breeds) > 0 && !slices.Contains(filter.Breeds, pet.Breed) {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, pet.Status) {
+		return false
+	}
+	if filter.Text != "" && !matchesText(filter.Text, pet) {
+		return false
+	}
+	return true
+}
+
+// matchesText приближённо повторяет поведение текстового индекса MongoDB:
+// животное подходит, если хотя бы одно слово запроса встречается в имени, породе или описании
+func matchesText(text string, pet *models.Pet) bool {
+	words := map[string]bool{}
+	for _, word := range splitWords(pet.Name + " " + pet.Breed + " " + pet.Description) {
+		words[word] = true
+	}
+	for _, word := range splitWords(text) {
+		if words[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// splitWords разбивает строку на слова в нижнем регистре
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ParsePetFilter строит фильтр по параметрам запроса GET /pets. Отсутствующие параметры не участвуют в фильтрации,
+// кроме статуса: без параметра status ищутся только животные, доступные для усыновления.
+// Параметры, не относящиеся к фильтру (например, параметры страницы), игнорируются
+func ParsePetFilter(values url.Values) (PetFilter, error) {
+	filter := PetFilter{
+		NamePrefix: strings.TrimSpace(values.Get("name")),
+		Gender:     values.Get("gender"),
+		Species:    splitList(values.Get("species")),
+		Breeds:     splitList(values.Get("breed")),
+		Text:       strings.TrimSpace(values.Get("q")),
+		Statuses:   splitList(values.Get("status")),
+	}
+
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []string{models.PetAvailable}
+	}
+	for _, status := range filter.Statuses {
+		if !models.IsPetStatus(status) {
+			return filter, errors.New("invalid status " + status)
+		}
+	}
+
+	if shelterID := values.Get("shelter_id"); shelterID != "" {
+		objectID, err := primitive.ObjectIDFromHex(shelterID)
+		if err != nil {
+			return filter, errors.New("invalid shelter ID")
+		}
+		filter.ShelterID = objectID
+	}
+
+	var err error
+	if filter.AgeMin, err = parseIntValue(values, "age_min"); err != nil {
+		return filter, err
+	}
+	if filter.AgeMax, err = parseIntValue(values, "age_max"); err != nil {
+		return filter, err
+	}
+
+	// Точный возраст - частный случай диапазона
+	age, err := parseIntValue(values, "age")
+	if err != nil {
+		return filter, err
+	}
+	if age != nil {
+		filter.AgeMin, filter.AgeMax = age, age
+	}
+
+	if filter.AgeMin != nil && filter.AgeMax != nil && *filter.AgeMin > *filter.AgeMax {
+		return filter, errors.New("age_min must not be greater than age_max")
+	}
+
+	return filter, nil
+}
+
+// Values возвращает параметры запроса, из которых ParsePetFilter построит такой же фильтр
+func (filter PetFilter) Values() url.Values {
+	values := url.Values{}
+	if !filter.ShelterID.IsZero() {
+		values.Set("shelter_id", filter.ShelterID.Hex())
+	}
+	if filter.NamePrefix != "" {
+		values.Set("name", filter.NamePrefix)
+	}
+	if filter.AgeMin != nil {
+		values.Set("age_min", strconv.Itoa(*filter.AgeMin))
+	}
+	if filter.AgeMax != nil {
+		values.Set("age_max", strconv.Itoa(*filter.AgeMax))
+	}
+	if filter.Gender != "" {
+		values.Set("gender", filter.Gender)
+	}
+	if len(filter.Species) > 0 {
+		values.Set("species", strings.Join(filter.Species, ","))
+	}
+	if len(filter.Breeds) > 0 {
+		values.Set("breed", strings.Join(filter.Breeds, ","))
+	}
+	if filter.Text != "" {
+		values.Set("q", filter.Text)
+	}
+	if len(filter.Statuses) > 0 && !slices.Equal(filter.Statuses, []string{models.PetAvailable}) {
+		values.Set("status", strings.Join(filter.Statuses, ","))
+	}
+	return values
+}
+
+// parseIntValue - вспомогательная функция, разбирающая необязательный неотрицательный целочисленный параметр
+func parseIntValue(values url.Values, name string) (*int, error) {
+	value := values.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return nil, errors.New("invalid " + name)
+	}
+	return &number, nil
+}
+
+// splitList - вспомогательная функция, разбивающая список значений, перечисленных через запятую
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -0,0 +1,215 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"myproject/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNotFound возвращается хранилищем, если запрошенный объект не существует
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict возвращается хранилищем, если объект был изменён параллельно
+// и операция не может быть применена к его текущему состоянию
+var ErrConflict = errors.New("conflict")
+
+// PetStore - хранилище домашних животных
+type PetStore interface {
+	GetPet(ctx context.Context, id primitive.ObjectID) (*models.Pet, error)
+	// GetPets возвращает животных с идентификаторами ids одним запросом. Отсутствующих животных
+	// и животных в корзине в результате нет
+	GetPets(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]models.Pet, error)
+	FindPets(ctx context.Context, filter PetFilter, page PageRequest) (*PetPage, error)
+	CreatePet(ctx context.Context, pet *models.Pet) error
+	UpdatePet(ctx context.Context, id primitive.ObjectID, pet *models.Pet) error
+	// DeletePet перемещает животное в корзину. Животные в корзине не возвращаются остальными методами,
+	// кроме методов работы с корзиной, и не изменяются
+	DeletePet(ctx context.Context, id primitive.ObjectID, deletedBy primitive.ObjectID) error
+	GetDeletedPet(ctx context.Context, id primitive.ObjectID) (*models.Pet, error)
+	// FindDeletedPets возвращает животных из корзины, начиная с последних удалённых. Нулевой shelterID - животных всех приютов
+	FindDeletedPets(ctx context.Context, shelterID primitive.ObjectID) ([]models.Pet, error)
+	// RestorePet возвращает животное из корзины, если его там нет - возвращает ErrNotFound
+	RestorePet(ctx context.Context, id primitive.ObjectID) error
+	// PurgeDeletedPets окончательно удаляет животных, попавших в корзину раньше deletedBefore, и возвращает их
+	PurgeDeletedPets(ctx context.Context, deletedBefore time.Time) ([]models.Pet, error)
+	// SetPetStatus переводит животное в статус change.To и добавляет change в историю статусов.
+	// Если текущий статус животного отличается от change.From, возвращает ErrConflict
+	SetPetStatus(ctx context.Context, change *models.PetStatusChange) error
+	// AddPetStatusChange добавляет запись в историю статусов, не изменяя животное (используется при создании животного)
+	AddPetStatusChange(ctx context.Context, change *models.PetStatusChange) error
+	// FindPetStatusChanges возвращает историю статусов животного в хронологическом порядке
+	FindPetStatusChanges(ctx context.Context, petID primitive.ObjectID) ([]models.PetStatusChange, error)
+	AddPetPhoto(ctx context.Context, id primitive.ObjectID, photo *models.Photo) error
+	// SetPetPhotos заменяет список фотографий животного (используется для сортировки, выбора обложки и удаления).
+	// Если текущий список отличается от current, возвращает ErrConflict
+	SetPetPhotos(ctx context.Context, id primitive.ObjectID, current, photos []models.Photo) error
+}
+
+// UserStore - хранилище пользователей
+type UserStore interface {
+	GetUser(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	// FindUsersByEmail возвращает пользователей с адресом email. Адрес не уникален: несколько аккаунтов могут использовать один адрес
+	FindUsersByEmail(ctx context.Context, email string) ([]models.User, error)
+	// CreateUser добавляет пользователя. Если имя пользователя занято, возвращает ErrConflict
+	CreateUser(ctx context.Context, user *models.User) error
+	// SetEmailVerified помечает адрес пользователя подтверждённым, если он всё ещё равен email. Иначе возвращает ErrConflict
+	SetEmailVerified(ctx context.Context, id primitive.ObjectID, email string) error
+	// SetPassword заменяет хеш пароля пользователя
+	SetPassword(ctx context.Context, id primitive.ObjectID, hash string) error
+	// SetUserRole назначает пользователю роль и приют, к которому она относится
+	SetUserRole(ctx context.Context, id primitive.ObjectID, role string, shelterID primitive.ObjectID) error
+	// FindUsers возвращает страницу пользователей, подходящих под фильтр
+	FindUsers(ctx context.Context, filter UserFilter, page PageRequest) (*UserPage, error)
+	// UpdateProfile заменяет профиль пользователя
+	UpdateProfile(ctx context.Context, id primitive.ObjectID, profile models.UserProfile) error
+	// SetEmail заменяет адрес почты пользователя и снимает отметку о его подтверждении
+	SetEmail(ctx context.Context, id primitive.ObjectID, email string) error
+	// SetUserDisabled отключает или снова включает аккаунт. Удалённый аккаунт включить нельзя: возвращается ErrNotFound
+	SetUserDisabled(ctx context.Context, id primitive.ObjectID, disabled bool) error
+	// AnonymizeUser удаляет аккаунт: заменяет имя пользователя на обезличенное, стирает пароль, адрес почты и профиль,
+	// возвращает роль user и отключает аккаунт. Если аккаунт уже удалён, возвращает ErrNotFound
+	AnonymizeUser(ctx context.Context, id primitive.ObjectID, deletedAt time.Time) error
+}
+
+// ShelterStore - хранилище приютов
+type ShelterStore interface {
+	GetShelter(ctx context.Context, id primitive.ObjectID) (*models.Shelter, error)
+	FindShelters(ctx context.Context) ([]models.Shelter, error)
+	CreateShelter(ctx context.Context, shelter *models.Shelter) error
+	UpdateShelter(ctx context.Context, id primitive.ObjectID, shelter *models.Shelter) error
+	DeleteShelter(ctx context.Context, id primitive.ObjectID) error
+}
+
+// ApplicationFilter - параметры поиска заявок на усыновление. Пустые поля не участвуют в фильтрации
+type ApplicationFilter struct {
+	PetID  primitive.ObjectID
+	UserID primitive.ObjectID
+	Status string
+}
+
+// ApplicationStore - хранилище заявок на усыновление
+type ApplicationStore interface {
+	GetApplication(ctx context.Context, id primitive.ObjectID) (*models.Application, error)
+	FindApplications(ctx context.Context, filter ApplicationFilter) ([]models.Application, error)
+	CreateApplication(ctx context.Context, application *models.Application) error
+	// UpdateApplicationStatus переводит заявку из статуса from в статус to.
+	// Если текущий статус заявки отличается от from, возвращает ErrConflict
+	UpdateApplicationStatus(ctx context.Context, id primitive.ObjectID, from, to, comment string) error
+	// CloseApplicationsForPet отклоняет все открытые заявки на животное, кроме заявки except
+	CloseApplicationsForPet(ctx context.Context, petID, except primitive.ObjectID, comment string) error
+}
+
+// TokenStore - хранилище токенов обновления, списка отозванных токенов доступа
+// и одноразовых токенов подтверждения почты и сброса пароля
+type TokenStore interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// MarkRefreshTokenUsed помечает токен обновления использованным.
+	// Если токен уже был использован или отозван, возвращает ErrConflict
+	MarkRefreshTokenUsed(ctx context.Context, id primitive.ObjectID) error
+	// RevokeTokenFamily отзывает все токены обновления семейства
+	RevokeTokenFamily(ctx context.Context, familyID string) error
+	// RevokeUserTokens отзывает все токены обновления пользователя и возвращает семейства ещё не истёкших токенов
+	RevokeUserTokens(ctx context.Context, userID primitive.ObjectID) ([]string, error)
+	// RevokeToken добавляет ID токена или семейства токенов в список отозванных до момента expiresAt
+	RevokeToken(ctx context.Context, id string, expiresAt time.Time) error
+	// IsTokenRevoked проверяет, отозван ли хотя бы один из ids
+	IsTokenRevoked(ctx context.Context, ids ...string) (bool, error)
+	CreateAccountToken(ctx context.Context, token *models.AccountToken) error
+	// GetAccountToken возвращает действующий токен с хешем hash и назначением purpose, не используя его.
+	// Если токена нет, он истёк или уже использован, возвращает ErrNotFound
+	GetAccountToken(ctx context.Context, hash, purpose string) (*models.AccountToken, error)
+	// UseAccountToken помечает токен с хешем hash и назначением purpose использованным и возвращает его.
+	// Если токена нет, он истёк или уже использован, возвращает ErrNotFound
+	UseAccountToken(ctx context.Context, hash, purpose string) (*models.AccountToken, error)
+	// DeleteAccountTokens удаляет все токены пользователя с назначением purpose
+	DeleteAccountTokens(ctx context.Context, userID primitive.ObjectID, purpose string) error
+}
+
+// LoginAttemptStore - хранилище неудачных попыток входа. Записи с истёкшим ExpiresAt считаются отсутствующими
+type LoginAttemptStore interface {
+	// GetLoginAttempts возвращает попытки входа под именем username, если их нет - возвращает ErrNotFound
+	GetLoginAttempts(ctx context.Context, username string) (*models.LoginAttempts, error)
+	// RecordLoginFailure увеличивает счётчик неудачных попыток, продлевает хранение записи до expiresAt
+	// и возвращает обновлённую запись. Если прежняя запись истекла, счёт начинается заново
+	RecordLoginFailure(ctx context.Context, username string, expiresAt time.Time) (*models.LoginAttempts, error)
+	// LockLogin запрещает вход под именем username до момента until
+	LockLogin(ctx context.Context, username string, until time.Time) error
+	// ResetLoginAttempts удаляет счётчик попыток после успешного входа
+	ResetLoginAttempts(ctx context.Context, username string) error
+}
+
+// RoleStore - хранилище ролей и их прав доступа
+type RoleStore interface {
+	GetRole(ctx context.Context, name string) (*models.Role, error)
+	FindRoles(ctx context.Context) ([]models.Role, error)
+	// SaveRole создаёт роль или заменяет существующую роль с тем же именем
+	SaveRole(ctx context.Context, role *models.Role) error
+	DeleteRole(ctx context.Context, name string) error
+}
+
+// FavoriteStore - хранилище избранных домашних животных пользователей
+type FavoriteStore interface {
+	// AddFavorite добавляет животное в избранное. Повторное добавление не считается ошибкой
+	AddFavorite(ctx context.Context, favorite *models.Favorite) error
+	// RemoveFavorite удаляет животное из избранного, если его там нет - возвращает ErrNotFound
+	RemoveFavorite(ctx context.Context, userID, petID primitive.ObjectID) error
+	// FindFavorites возвращает избранное пользователя, начиная с последних добавленных
+	FindFavorites(ctx context.Context, userID primitive.ObjectID) ([]models.Favorite, error)
+	// CountFavorites возвращает число пользователей, добавивших в избранное каждое из животных petIDs
+	CountFavorites(ctx context.Context, petIDs []primitive.ObjectID) (map[primitive.ObjectID]int, error)
+	// FavoritePetIDs возвращает те из petIDs, которые пользователь добавил в избранное
+	FavoritePetIDs(ctx context.Context, userID primitive.ObjectID, petIDs []primitive.ObjectID) (map[primitive.ObjectID]bool, error)
+	// DeleteFavoritesForPet удаляет животное из избранного всех пользователей
+	DeleteFavoritesForPet(ctx context.Context, petID primitive.ObjectID) error
+	// DeleteFavoritesForUser удаляет всё избранное пользователя
+	DeleteFavoritesForUser(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// SearchStore - хранилище сохранённых поисков и найденных по ним домашних животных
+type SearchStore interface {
+	CreateSavedSearch(ctx context.Context, search *models.SavedSearch) error
+	GetSavedSearch(ctx context.Context, id primitive.ObjectID) (*models.SavedSearch, error)
+	// FindSavedSearches возвращает поиски пользователя, а при нулевом userID - поиски всех пользователей
+	FindSavedSearches(ctx context.Context, userID primitive.ObjectID) ([]models.SavedSearch, error)
+	// DeleteSavedSearch удаляет поиск вместе с найденными по нему животными
+	DeleteSavedSearch(ctx context.Context, id primitive.ObjectID) error
+	// AddSearchMatch записывает найденное животное. Если животное уже было найдено этим поиском, возвращает ErrConflict
+	AddSearchMatch(ctx context.Context, match *models.SearchMatch) error
+	// FindPendingMatches возвращает найденных животных, о которых ещё не было уведомления, в порядке нахождения
+	FindPendingMatches(ctx context.Context) ([]models.SearchMatch, error)
+	MarkMatchesNotified(ctx context.Context, ids []primitive.ObjectID) error
+}
+
+// NotificationStore - хранилище уведомлений внутреннего почтового ящика
+type NotificationStore interface {
+	CreateNotification(ctx context.Context, notification *models.Notification) error
+	// FindNotifications возвращает уведомления пользователя, начиная с последних
+	FindNotifications(ctx context.Context, userID primitive.ObjectID) ([]models.Notification, error)
+	// MarkNotificationRead помечает уведомление пользователя прочитанным, если его нет - возвращает ErrNotFound
+	MarkNotificationRead(ctx context.Context, userID, id primitive.ObjectID) error
+	// DeleteNotificationsForUser удаляет все уведомления пользователя
+	DeleteNotificationsForUser(ctx context.Context, userID primitive.ObjectID) error
+}
+
+// AuditFilter - параметры поиска в журнале аудита. Пустые поля не участвуют в фильтрации
+type AuditFilter struct {
+	ActorID  primitive.ObjectID
+	Entity   string
+	EntityID string
+	Action   string
+	// From и To - границы интервала времени, From включается, To - нет
+	From time.Time
+	To   time.Time
+}
+
+// AuditStore - хранилище журнала аудита. Записи только добавляются
+type AuditStore interface {
+	RecordAudit(ctx context.Context, entry *models.AuditEntry) error
+	// FindAudit возвращает не более limit записей, начиная с последних
+	FindAudit(ctx context.Context, filter AuditFilter, limit int) ([]models.AuditEntry, error)
+}
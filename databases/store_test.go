@@ -0,0 +1,475 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+	"myproject/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Проверки ниже описывают контракт интерфейсов хранилищ и не зависят от реализации.
+// Хранилище в памяти проверяется ими напрямую, реализация поверх MongoDB должна вести себя так же
+
+func TestMemoryPetStore(t *testing.T) {
+	testPetStore(t, func() PetStore { return CreateMemoryPetStore() })
+}
+
+func TestMemoryUserStore(t *testing.T) {
+	testUserStore(t, func() UserStore { return CreateMemoryUserStore() })
+}
+
+func TestMemoryLoginAttemptStore(t *testing.T) {
+	testLoginAttemptStore(t, func() LoginAttemptStore { return CreateMemoryLoginAttemptStore() })
+}
+
+// testPetStore проверяет контракт PetStore на хранилищах, создаваемых create
+func testPetStore(t *testing.T, create func() PetStore) {
+	ctx := context.Background()
+	shelterID := primitive.NewObjectID()
+
+	// createPets добавляет животных с именами names в новое хранилище
+	createPets := func(t *testing.T, names ...string) (PetStore, []models.Pet) {
+		store := create()
+		pets := make([]models.Pet, len(names))
+		for i, name := range names {
+			pets[i] = models.Pet{ShelterID: shelterID, Name: name, Age: i, Species: "dog", Status: models.PetAvailable,
+				CreatedAt: time.Date(2026, time.January, 1+i, 0, 0, 0, 0, time.UTC)}
+			if err := store.CreatePet(ctx, &pets[i]); err != nil {
+				t.Fatalf("CreatePet: %v", err)
+			}
+			if pets[i].ID.IsZero() {
+				t.Fatal("CreatePet did not assign ID")
+			}
+		}
+		return store, pets
+	}
+
+	t.Run("get", func(t *testing.T) {
+		store, pets := createPets(t, "Rex")
+
+		pet, err := store.GetPet(ctx, pets[0].ID)
+		if err != nil || pet.Name != "Rex" {
+			t.Fatalf("GetPet = %v, %v", pet, err)
+		}
+		if _, err := store.GetPet(ctx, primitive.NewObjectID()); err != ErrNotFound {
+			t.Fatalf("GetPet of missing pet: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("get batch skips missing and trashed pets", func(t *testing.T) {
+		store, pets := createPets(t, "Rex", "Tom", "Bim")
+		if err := store.DeletePet(ctx, pets[1].ID, primitive.NewObjectID()); err != nil {
+			t.Fatalf("DeletePet: %v", err)
+		}
+
+		found, err := store.GetPets(ctx, []primitive.ObjectID{pets[0].ID, pets[1].ID, pets[2].ID, primitive.NewObjectID()})
+		if err != nil {
+			t.Fatalf("GetPets: %v", err)
+		}
+		if len(found) != 2 || found[pets[0].ID].Name != "Rex" || found[pets[2].ID].Name != "Bim" {
+			t.Fatalf("GetPets = %v, want Rex and Bim", found)
+		}
+
+		found, err = store.GetPets(ctx, nil)
+		if err != nil || len(found) != 0 {
+			t.Fatalf("GetPets(nil) = %v, %v", found, err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		store, pets := createPets(t, "Rex")
+
+		update := pets[0]
+		update.Name = "Max"
+		if err := store.UpdatePet(ctx, pets[0].ID, &update); err != nil {
+			t.Fatalf("UpdatePet: %v", err)
+		}
+		if pet, _ := store.GetPet(ctx, pets[0].ID); pet.Name != "Max" {
+			t.Fatalf("name = %q after update, want Max", pet.Name)
+		}
+		if err := store.UpdatePet(ctx, primitive.NewObjectID(), &update); err != ErrNotFound {
+			t.Fatalf("UpdatePet of missing pet: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("photos", func(t *testing.T) {
+		store, pets := createPets(t, "Rex")
+		id := pets[0].ID
+		first := models.Photo{ID: primitive.NewObjectID(), Key: "first.jpg", Cover: true}
+		second := models.Photo{ID: primitive.NewObjectID(), Key: "second.jpg"}
+		for _, photo := range []models.Photo{first, second} {
+			if err := store.AddPetPhoto(ctx, id, &photo); err != nil {
+				t.Fatalf("AddPetPhoto: %v", err)
+			}
+		}
+
+		// Замена по устаревшему списку не должна терять фотографию, добавленную параллельно
+		if err := store.SetPetPhotos(ctx, id, []models.Photo{first}, nil); err != ErrConflict {
+			t.Fatalf("SetPetPhotos with stale photos: err = %v, want ErrConflict", err)
+		}
+		if err := store.SetPetPhotos(ctx, id, []models.Photo{first, second}, []models.Photo{second, first}); err != nil {
+			t.Fatalf("SetPetPhotos: %v", err)
+		}
+		if pet, _ := store.GetPet(ctx, id); len(pet.Photos) != 2 || pet.Photos[0].ID != second.ID {
+			t.Fatalf("photos = %v, want second first", pet.Photos)
+		}
+		if err := store.SetPetPhotos(ctx, id, []models.Photo{second, first}, []models.Photo{}); err != nil {
+			t.Fatalf("SetPetPhotos: %v", err)
+		}
+		if err := store.SetPetPhotos(ctx, id, nil, nil); err != nil {
+			t.Fatalf("SetPetPhotos of pet without photos: %v", err)
+		}
+		if err := store.SetPetPhotos(ctx, primitive.NewObjectID(), nil, nil); err != ErrNotFound {
+			t.Fatalf("SetPetPhotos of missing pet: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("trash and restore", func(t *testing.T) {
+		store, pets := createPets(t, "Rex")
+		id := pets[0].ID
+
+		if err := store.DeletePet(ctx, id, primitive.NewObjectID()); err != nil {
+			t.Fatalf("DeletePet: %v", err)
+		}
+		if _, err := store.GetPet(ctx, id); err != ErrNotFound {
+			t.Fatalf("GetPet of trashed pet: err = %v, want ErrNotFound", err)
+		}
+		if err := store.DeletePet(ctx, id, primitive.NewObjectID()); err != ErrNotFound {
+			t.Fatalf("DeletePet of trashed pet: err = %v, want ErrNotFound", err)
+		}
+		if page, _ := store.FindPets(ctx, PetFilter{}, PageRequest{}); page.Total != 0 {
+			t.Fatalf("FindPets returned %d trashed pets", page.Total)
+		}
+		if _, err := store.GetDeletedPet(ctx, id); err != nil {
+			t.Fatalf("GetDeletedPet: %v", err)
+		}
+
+		if err := store.RestorePet(ctx, id); err != nil {
+			t.Fatalf("RestorePet: %v", err)
+		}
+		if _, err := store.GetPet(ctx, id); err != nil {
+			t.Fatalf("GetPet after restore: %v", err)
+		}
+		if err := store.RestorePet(ctx, id); err != ErrNotFound {
+			t.Fatalf("RestorePet of active pet: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		store, pets := createPets(t, "Rex", "Tom")
+		if err := store.DeletePet(ctx, pets[0].ID, primitive.NewObjectID()); err != nil {
+			t.Fatalf("DeletePet: %v", err)
+		}
+
+		purged, err := store.PurgeDeletedPets(ctx, time.Now().Add(time.Minute))
+		if err != nil || len(purged) != 1 || purged[0].ID != pets[0].ID {
+			t.Fatalf("PurgeDeletedPets = %v, %v", purged, err)
+		}
+		if _, err := store.GetDeletedPet(ctx, pets[0].ID); err != ErrNotFound {
+			t.Fatalf("GetDeletedPet after purge: err = %v, want ErrNotFound", err)
+		}
+		if _, err := store.GetPet(ctx, pets[1].ID); err != nil {
+			t.Fatalf("GetPet of active pet after purge: %v", err)
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		store, pets := createPets(t, "Rex")
+		id := pets[0].ID
+
+		tests := []struct {
+			name string
+			from string
+			to   string
+			want error
+		}{
+			{"current status", models.PetAvailable, models.PetOnHold, nil},
+			{"stale status", models.PetAvailable, models.PetAdopted, ErrConflict},
+			{"next status", models.PetOnHold, models.PetAvailable, nil},
+		}
+		for _, test := range tests {
+			err := store.SetPetStatus(ctx, &models.PetStatusChange{PetID: id, From: test.from, To: test.to, ChangedAt: time.Now()})
+			if err != test.want {
+				t.Fatalf("%s: SetPetStatus err = %v, want %v", test.name, err, test.want)
+			}
+		}
+
+		history, err := store.FindPetStatusChanges(ctx, id)
+		if err != nil || len(history) != 2 || history[0].To != models.PetOnHold || history[1].To != models.PetAvailable {
+			t.Fatalf("FindPetStatusChanges = %v, %v", history, err)
+		}
+		if err := store.SetPetStatus(ctx, &models.PetStatusChange{PetID: primitive.NewObjectID(), From: models.PetAvailable, To: models.PetOnHold}); err != ErrNotFound {
+			t.Fatalf("SetPetStatus of missing pet: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		store, _ := createPets(t, "Bim", "Rex", "Ace", "Tom", "Max")
+
+		tests := []struct {
+			name string
+			page PageRequest
+			want []string
+		}{
+			{"default sort", PageRequest{Limit: 2}, []string{"Bim", "Rex"}},
+			{"offset", PageRequest{Limit: 2, Offset: 4}, []string{"Max"}},
+			{"by name", PageRequest{Sort: PetSortName, Limit: 3}, []string{"Ace", "Bim", "Max"}},
+			{"by name descending", PageRequest{Sort: PetSortName, Desc: true, Limit: 2}, []string{"Tom", "Rex"}},
+			{"offset past end", PageRequest{Limit: 2, Offset: 10}, nil},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				page, err := store.FindPets(ctx, PetFilter{}, test.page)
+				if err != nil {
+					t.Fatalf("FindPets: %v", err)
+				}
+				if page.Total != 5 {
+					t.Fatalf("total = %d, want 5", page.Total)
+				}
+				if got := petNames(page.Pets); fmt.Sprint(got) != fmt.Sprint(test.want) {
+					t.Fatalf("pets = %v, want %v", got, test.want)
+				}
+			})
+		}
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		store, _ := createPets(t, "Bim", "Rex", "Ace", "Tom", "Max")
+
+		var names []string
+		page := PageRequest{Sort: PetSortName, Limit: 2}
+		for {
+			result, err := store.FindPets(ctx, PetFilter{}, page)
+			if err != nil {
+				t.Fatalf("FindPets: %v", err)
+			}
+			names = append(names, petNames(result.Pets)...)
+			if result.NextCursor == "" {
+				break
+			}
+			page.Cursor = result.NextCursor
+		}
+		if want := []string{"Ace", "Bim", "Max", "Rex", "Tom"}; fmt.Sprint(names) != fmt.Sprint(want) {
+			t.Fatalf("pets = %v, want %v", names, want)
+		}
+
+		// Курсор другой сортировки не подходит к запросу
+		if _, err := store.FindPets(ctx, PetFilter{}, PageRequest{Sort: PetSortAge, Cursor: page.Cursor}); err != ErrInvalidCursor {
+			t.Fatalf("cursor of other sort: err = %v, want ErrInvalidCursor", err)
+		}
+		if _, err := store.FindPets(ctx, PetFilter{}, PageRequest{Cursor: "garbage"}); err != ErrInvalidCursor {
+			t.Fatalf("garbage cursor: err = %v, want ErrInvalidCursor", err)
+		}
+	})
+}
+
+// testUserStore проверяет контракт UserStore на хранилищах, создаваемых create
+func testUserStore(t *testing.T, create func() UserStore) {
+	ctx := context.Background()
+
+	// createUser добавляет в store пользователя username с адресом email
+	createUser := func(t *testing.T, store UserStore, username, email string) models.User {
+		user := models.User{Username: username, Email: email, Role: models.RoleUser, CreatedAt: time.Now()}
+		if err := store.CreateUser(ctx, &user); err != nil {
+			t.Fatalf("CreateUser(%s): %v", username, err)
+		}
+		return user
+	}
+
+	t.Run("create and get", func(t *testing.T) {
+		store := create()
+		user := createUser(t, store, "alice", "alice@example.com")
+
+		if got, err := store.GetUser(ctx, user.ID); err != nil || got.Username != "alice" {
+			t.Fatalf("GetUser = %v, %v", got, err)
+		}
+		if got, err := store.GetUserByUsername(ctx, "alice"); err != nil || got.ID != user.ID {
+			t.Fatalf("GetUserByUsername = %v, %v", got, err)
+		}
+		if _, err := store.GetUserByUsername(ctx, "bob"); err != ErrNotFound {
+			t.Fatalf("GetUserByUsername of missing user: err = %v, want ErrNotFound", err)
+		}
+
+		duplicate := models.User{Username: "alice", Role: models.RoleUser}
+		if err := store.CreateUser(ctx, &duplicate); err != ErrConflict {
+			t.Fatalf("CreateUser with taken username: err = %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("find by email", func(t *testing.T) {
+		store := create()
+		createUser(t, store, "alice", "shared@example.com")
+		createUser(t, store, "bob", "shared@example.com")
+		createUser(t, store, "carol", "carol@example.com")
+
+		users, err := store.FindUsersByEmail(ctx, "shared@example.com")
+		if err != nil || len(users) != 2 {
+			t.Fatalf("FindUsersByEmail = %v, %v", users, err)
+		}
+	})
+
+	t.Run("verify email", func(t *testing.T) {
+		store := create()
+		user := createUser(t, store, "alice", "alice@example.com")
+
+		if err := store.SetEmailVerified(ctx, user.ID, "old@example.com"); err != ErrConflict {
+			t.Fatalf("SetEmailVerified with stale address: err = %v, want ErrConflict", err)
+		}
+		if err := store.SetEmailVerified(ctx, user.ID, "alice@example.com"); err != nil {
+			t.Fatalf("SetEmailVerified: %v", err)
+		}
+		if got, _ := store.GetUser(ctx, user.ID); !got.EmailVerified {
+			t.Fatal("email is not verified")
+		}
+
+		if err := store.SetEmail(ctx, user.ID, "new@example.com"); err != nil {
+			t.Fatalf("SetEmail: %v", err)
+		}
+		if got, _ := store.GetUser(ctx, user.ID); got.Email != "new@example.com" || got.EmailVerified {
+			t.Fatalf("after SetEmail: email = %q, verified = %v", got.Email, got.EmailVerified)
+		}
+	})
+
+	t.Run("role", func(t *testing.T) {
+		store := create()
+		user := createUser(t, store, "alice", "")
+		shelterID := primitive.NewObjectID()
+
+		if err := store.SetUserRole(ctx, user.ID, models.RoleShelterStaff, shelterID); err != nil {
+			t.Fatalf("SetUserRole: %v", err)
+		}
+		if got, _ := store.GetUser(ctx, user.ID); got.Role != models.RoleShelterStaff || got.ShelterID != shelterID {
+			t.Fatalf("role = %q, shelter = %s", got.Role, got.ShelterID.Hex())
+		}
+		if err := store.SetUserRole(ctx, primitive.NewObjectID(), models.RoleUser, primitive.NilObjectID); err != ErrNotFound {
+			t.Fatalf("SetUserRole of missing user: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("disable and delete", func(t *testing.T) {
+		store := create()
+		user := createUser(t, store, "alice", "alice@example.com")
+
+		if err := store.SetUserDisabled(ctx, user.ID, true); err != nil {
+			t.Fatalf("SetUserDisabled: %v", err)
+		}
+		if got, _ := store.GetUser(ctx, user.ID); !got.Disabled {
+			t.Fatal("user is not disabled")
+		}
+
+		if err := store.AnonymizeUser(ctx, user.ID, time.Now()); err != nil {
+			t.Fatalf("AnonymizeUser: %v", err)
+		}
+		got, err := store.GetUser(ctx, user.ID)
+		if err != nil || got.DeletedAt == nil || got.Email != "" || got.Username == "alice" || !got.Disabled {
+			t.Fatalf("deleted user = %+v, %v", got, err)
+		}
+		if err := store.AnonymizeUser(ctx, user.ID, time.Now()); err != ErrNotFound {
+			t.Fatalf("AnonymizeUser of deleted user: err = %v, want ErrNotFound", err)
+		}
+		if err := store.SetUserDisabled(ctx, user.ID, false); err != ErrNotFound {
+			t.Fatalf("enabling deleted user: err = %v, want ErrNotFound", err)
+		}
+		// Имя удалённого пользователя освобождается
+		createUser(t, store, "alice", "")
+	})
+
+	t.Run("find", func(t *testing.T) {
+		store := create()
+		for _, username := range []string{"carol", "alice", "dave", "bob"} {
+			createUser(t, store, username, username+"@example.com")
+		}
+		dave, _ := store.GetUserByUsername(ctx, "dave")
+		if err := store.SetUserDisabled(ctx, dave.ID, true); err != nil {
+			t.Fatalf("SetUserDisabled: %v", err)
+		}
+		bob, _ := store.GetUserByUsername(ctx, "bob")
+		if err := store.AnonymizeUser(ctx, bob.ID, time.Now()); err != nil {
+			t.Fatalf("AnonymizeUser: %v", err)
+		}
+		disabled := true
+
+		tests := []struct {
+			name   string
+			filter UserFilter
+			page   PageRequest
+			want   []string
+			total  int64
+		}{
+			{"all active accounts", UserFilter{}, PageRequest{Sort: UserSortUsername, Limit: 10}, []string{"alice", "carol", "dave"}, 3},
+			{"page", UserFilter{}, PageRequest{Sort: UserSortUsername, Limit: 2, Offset: 2}, []string{"dave"}, 3},
+			{"query ignores case", UserFilter{Query: "CAR"}, PageRequest{Sort: UserSortUsername, Limit: 10}, []string{"carol"}, 1},
+			{"disabled", UserFilter{Disabled: &disabled}, PageRequest{Sort: UserSortUsername, Limit: 10}, []string{"dave"}, 1},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				page, err := store.FindUsers(ctx, test.filter, test.page)
+				if err != nil {
+					t.Fatalf("FindUsers: %v", err)
+				}
+				if got := usernames(page.Users); fmt.Sprint(got) != fmt.Sprint(test.want) || page.Total != test.total {
+					t.Fatalf("users = %v (total %d), want %v (total %d)", got, page.Total, test.want, test.total)
+				}
+			})
+		}
+	})
+}
+
+// testLoginAttemptStore проверяет контракт LoginAttemptStore на хранилищах, создаваемых create
+func testLoginAttemptStore(t *testing.T, create func() LoginAttemptStore) {
+	ctx := context.Background()
+	store := create()
+	expiresAt := time.Now().Add(time.Hour)
+
+	for want := 1; want <= 2; want++ {
+		attempts, err := store.RecordLoginFailure(ctx, "alice", expiresAt)
+		if err != nil || attempts.Failures != want {
+			t.Fatalf("RecordLoginFailure = %v, %v, want %d failures", attempts, err, want)
+		}
+	}
+	if err := store.LockLogin(ctx, "alice", expiresAt); err != nil {
+		t.Fatalf("LockLogin: %v", err)
+	}
+	if attempts, err := store.GetLoginAttempts(ctx, "alice"); err != nil || attempts.Failures != 2 || attempts.LockedUntil.IsZero() {
+		t.Fatalf("GetLoginAttempts = %v, %v", attempts, err)
+	}
+
+	// Истёкший счётчик начинается заново
+	if _, err := store.RecordLoginFailure(ctx, "bob", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("RecordLoginFailure: %v", err)
+	}
+	if _, err := store.GetLoginAttempts(ctx, "bob"); err != ErrNotFound {
+		t.Fatalf("GetLoginAttempts of expired attempts: err = %v, want ErrNotFound", err)
+	}
+	if attempts, err := store.RecordLoginFailure(ctx, "bob", expiresAt); err != nil || attempts.Failures != 1 {
+		t.Fatalf("RecordLoginFailure after expiry = %v, %v, want 1 failure", attempts, err)
+	}
+
+	if err := store.ResetLoginAttempts(ctx, "alice"); err != nil {
+		t.Fatalf("ResetLoginAttempts: %v", err)
+	}
+	if _, err := store.GetLoginAttempts(ctx, "alice"); err != ErrNotFound {
+		t.Fatalf("GetLoginAttempts after reset: err = %v, want ErrNotFound", err)
+	}
+}
+
+// petNames возвращает имена животных pets
+func petNames(pets []models.Pet) []string {
+	var names []string
+	for _, pet := range pets {
+		names = append(names, pet.Name)
+	}
+	return names
+}
+
+// usernames возвращает имена пользователей users
+func usernames(users []models.User) []string {
+	var names []string
+	for _, user := range users {
+		names = append(names, user.Username)
+	}
+	return names
+}
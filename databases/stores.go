@@ -0,0 +1,121 @@
+package databases
+
+import (
+	"context"
+	"myproject/models"
+)
+
+// Stores - набор хранилищ, используемых приложением
+type Stores struct {
+	Pets          PetStore
+	Users         UserStore
+	Applications  ApplicationStore
+	Shelters      ShelterStore
+	Tokens        TokenStore
+	LoginAttempts LoginAttemptStore
+	Roles         RoleStore
+	Favorites     FavoriteStore
+	Searches      SearchStore
+	Notifications NotificationStore
+	Audit         AuditStore
+}
+
+// CreateMemoryStores создаёт хранилища в оперативной памяти
+func CreateMemoryStores() *Stores {
+	return &Stores{
+		Pets:          CreateMemoryPetStore(),
+		Users:         CreateMemoryUserStore(),
+		Applications:  CreateMemoryApplicationStore(),
+		Shelters:      CreateMemoryShelterStore(),
+		Tokens:        CreateMemoryTokenStore(),
+		LoginAttempts: CreateMemoryLoginAttemptStore(),
+		Roles:         CreateMemoryRoleStore(),
+		Favorites:     CreateMemoryFavoriteStore(),
+		Searches:      CreateMemorySearchStore(),
+		Notifications: CreateMemoryNotificationStore(),
+		Audit:         CreateMemoryAuditStore(),
+	}
+}
+
+// CreateMongoStores создаёт хранилища поверх MongoDB и индексы их коллекций
+func CreateMongoStores(ctx context.Context, database *MongoDB) (*Stores, error) {
+	pets := CreateMongoPetStore(database)
+	if err := pets.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+	if err := pets.MigrateStatuses(ctx); err != nil {
+		return nil, err
+	}
+
+	users := CreateMongoUserStore(database)
+	if err := users.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	tokens := CreateMongoTokenStore(database)
+	if err := tokens.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	loginAttempts := CreateMongoLoginAttemptStore(database)
+	if err := loginAttempts.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	favorites := CreateMongoFavoriteStore(database)
+	if err := favorites.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	searches := CreateMongoSearchStore(database)
+	if err := searches.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	notifications := CreateMongoNotificationStore(database)
+	if err := notifications.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	audit := CreateMongoAuditStore(database)
+	if err := audit.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	return &Stores{
+		Pets:          pets,
+		Users:         users,
+		Applications:  CreateMongoApplicationStore(database),
+		Shelters:      CreateMongoShelterStore(database),
+		Tokens:        tokens,
+		LoginAttempts: loginAttempts,
+		Roles:         CreateMongoRoleStore(database),
+		Favorites:     favorites,
+		Searches:      searches,
+		Notifications: notifications,
+		Audit:         audit,
+	}, nil
+}
+
+// SeedDefaultRoles создаёт встроенные роли, которых ещё нет в хранилище.
+// Уже существующие роли не изменяются, чтобы не затереть настроенные администратором права,
+// кроме роли admin: ей добавляются права, появившиеся в новых версиях
+func SeedDefaultRoles(ctx context.Context, roles RoleStore) error {
+	for _, role := range models.DefaultRoles() {
+		existing, err := roles.GetRole(ctx, role.Name)
+		if err == nil {
+			// Права ролей не повторяются и проверяются при сохранении, поэтому совпадение числа означает наличие всех прав
+			if role.Name != models.RoleAdmin || len(existing.Permissions) == len(models.AllPermissions) {
+				continue
+			}
+			role.Description = existing.Description
+		} else if err != ErrNotFound {
+			return err
+		}
+
+		if err := roles.SaveRole(ctx, &role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
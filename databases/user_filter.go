@@ -0,0 +1,126 @@
+package databases
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"myproject/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Поля, по которым можно сортировать список пользователей
+const (
+	UserSortUsername = "username"
+	UserSortCreated  = "created_at"
+)
+
+// UserFilter - параметры поиска пользователей. Пустые поля не участвуют в фильтрации.
+// Удалённые аккаунты в поиск не попадают
+type UserFilter struct {
+	// Query - подстрока имени пользователя, адреса почты или отображаемого имени, сравнивается без учёта регистра
+	Query    string
+	Role     string
+	Disabled *bool
+}
+
+// UserPage - страница списка пользователей
+type UserPage struct {
+	Users []models.User
+	Total int64
+	// NextCursor - курсор следующей страницы, пустой на последней странице
+	NextCursor string
+}
+
+// Matches проверяет, подходит ли пользователь под фильтр. Используется хранилищем в памяти
+func (filter UserFilter) Matches(user *models.User) bool {
+	if user.DeletedAt != nil {
+		return false
+	}
+	if filter.Role != "" && user.Role != filter.Role {
+		return false
+	}
+	if filter.Disabled != nil && user.Disabled != *filter.Disabled {
+		return false
+	}
+	if filter.Query != "" {
+		query := strings.ToLower(filter.Query)
+		return strings.Contains(strings.ToLower(user.Username), query) ||
+			strings.Contains(strings.ToLower(user.Email), query) ||
+			strings.Contains(strings.ToLower(user.Profile.DisplayName), query)
+	}
+	return true
+}
+
+// IsUserSort проверяет, поддерживается ли сортировка пользователей по полю sort
+func IsUserSort(sort string) bool {
+	return sort == UserSortUsername || sort == UserSortCreated
+}
+
+// encodeUserCursor формирует курсор, указывающий на user
+func encodeUserCursor(page PageRequest, user *models.User) string {
+	value, _ := json.Marshal(userSortValue(user, page.Sort))
+	data, _ := json.Marshal(pageCursor{Sort: page.Sort, Desc: page.Desc, Value: value, ID: user.ID.Hex()})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeUserCursor разбирает курсор и возвращает пользователя, на котором закончилась предыдущая страница.
+// У пользователя заполнены только ID и поле сортировки
+func decodeUserCursor(page PageRequest) (*models.User, error) {
+	cursor, id, err := decodePageCursor(page)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{ID: id}
+	if page.Sort == UserSortUsername {
+		err = json.Unmarshal(cursor.Value, &user.Username)
+	} else {
+		err = json.Unmarshal(cursor.Value, &user.CreatedAt)
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return user, nil
+}
+
+// userSortValue возвращает значение поля сортировки пользователя
+func userSortValue(user *models.User, sort string) interface{} {
+	if sort == UserSortUsername {
+		return user.Username
+	}
+	return user.CreatedAt
+}
+
+// compareUsers сравнивает пользователей по полю сортировки, а при равенстве - по ID
+func compareUsers(a, b *models.User, sort string) int {
+	var result int
+	if sort == UserSortUsername {
+		result = strings.Compare(a.Username, b.Username)
+	} else {
+		result = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if result != 0 {
+		return result
+	}
+	return strings.Compare(a.ID.Hex(), b.ID.Hex())
+}
+
+// anonymizedUsername возвращает имя, которое получает аккаунт id после удаления. Оно уникально
+// и проходит правило username, поэтому не конфликтует с именами других пользователей
+func anonymizedUsername(id primitive.ObjectID) string {
+	return "deleted-" + id.Hex()
+}
+
+// anonymizedUser возвращает аккаунт user после удаления в момент deletedAt
+func anonymizedUser(user models.User, deletedAt time.Time) models.User {
+	return models.User{
+		ID:        user.ID,
+		Username:  anonymizedUsername(user.ID),
+		Role:      models.RoleUser,
+		Disabled:  true,
+		DeletedAt: &deletedAt,
+		CreatedAt: user.CreatedAt,
+	}
+}
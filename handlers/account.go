@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"myproject/apierror"
+	"myproject/databases"
+	"myproject/logging"
+	"myproject/middlewares"
+	"myproject/models"
+	"myproject/validation"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// accountEmailAccepted - ответ на запрос письма. Он не зависит от того, зарегистрирован ли адрес,
+// чтобы по нему нельзя было узнать адреса пользователей
+var accountEmailAccepted = gin.H{"status": "if the address is registered, an email has been sent"}
+
+// RequestEmailVerification отправляет письмо со ссылкой подтверждения на адрес пользователя
+// @Summary Запрос письма подтверждения почты
+// @Description Отправляет новое письмо со ссылкой подтверждения на адрес, если он принадлежит пользователю и ещё не подтверждён. Ранее отправленные ссылки перестают действовать. Ответ не зависит от того, зарегистрирован ли адрес
+// @Tags Пользователи
+// @Accept json
+// @Produce json
+// @Param email body object true "email"
+// @Success 202 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 429 {object} apierror.Problem "Слишком много запросов, см. заголовок Retry-After"
+// @Failure 500 {object} apierror.Problem
+// @Router /email/verification [post]
+func (handler *UserHandler) RequestEmailVerification(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email,max=254"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	users, err := handler.users.FindUsersByEmail(c.Request.Context(), input.Email)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve users", err))
+		return
+	}
+	for _, user := range users {
+		if !user.EmailVerified {
+			handler.sendVerification(c.Request.Context(), &user)
+		}
+	}
+
+	c.JSON(http.StatusAccepted, accountEmailAccepted)
+}
+
+// ConfirmEmailVerification подтверждает адрес почты по токену из письма
+// @Summary Подтверждение почты
+// @Description Подтверждает адрес почты пользователя по токену из письма. Токен одноразовый
+// @Tags Пользователи
+// @Accept json
+// @Produce json
+// @Param token body object true "token"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem "Токен неверен, истёк или уже использован"
+// @Failure 409 {object} apierror.Problem "Адрес пользователя изменился после отправки письма"
+// @Failure 500 {object} apierror.Problem
+// @Router /email/verification/confirm [post]
+func (handler *UserHandler) ConfirmEmailVerification(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	token, err := handler.auth.UseAccountToken(c.Request.Context(), input.Token, models.AccountTokenVerifyEmail)
+	if err == middlewares.ErrInvalidAccountToken {
+		c.Error(apierror.Validation("Invalid or expired token"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to verify email", err))
+		return
+	}
+
+	err = handler.users.SetEmailVerified(c.Request.Context(), token.UserID, token.Email)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.Validation("Invalid or expired token"))
+		return
+	} else if err == databases.ErrConflict {
+		c.Error(apierror.Conflict("Email address has changed since the token was sent"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to verify email", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "email verified"})
+}
+
+// RequestPasswordReset отправляет письмо со ссылкой сброса пароля
+// @Summary Запрос сброса пароля
+// @Description Отправляет письмо со ссылкой сброса пароля каждому аккаунту с этим адресом. Письмо отправляется только на подтверждённый адрес. Ответ не зависит от того, зарегистрирован ли адрес
+// @Tags Пользователи
+// @Accept json
+// @Produce json
+// @Param email body object true "email"
+// @Success 202 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 429 {object} apierror.Problem "Слишком много запросов, см. заголовок Retry-After"
+// @Failure 500 {object} apierror.Problem
+// @Router /password/reset [post]
+func (handler *UserHandler) RequestPasswordReset(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email,max=254"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	users, err := handler.users.FindUsersByEmail(c.Request.Context(), input.Email)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve users", err))
+		return
+	}
+	// Неподтверждённый адрес мог быть указан с ошибкой и принадлежать другому человеку
+	for _, user := range users {
+		if user.EmailVerified {
+			handler.sendPasswordReset(c.Request.Context(), &user)
+		}
+	}
+
+	c.JSON(http.StatusAccepted, accountEmailAccepted)
+}
+
+// ConfirmPasswordReset устанавливает новый пароль по токену из письма
+// @Summary Сброс пароля
+// @Description Устанавливает новый пароль по токену из письма и завершает все сессии пользователя. Токен одноразовый
+// @Tags Пользователи
+// @Accept json
+// @Produce json
+// @Param reset body object true "token и новый password"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem "Токен неверен, истёк или уже использован, либо пароль не подходит"
+// @Failure 500 {object} apierror.Problem
+// @Router /password/reset/confirm [post]
+func (handler *UserHandler) ConfirmPasswordReset(c *gin.Context) {
+	var input struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	// Пароль проверяется до использования токена, чтобы неподходящий пароль не израсходовал ссылку
+	token, err := handler.auth.CheckAccountToken(ctx, input.Token, models.AccountTokenResetPassword)
+	if err == middlewares.ErrInvalidAccountToken {
+		c.Error(apierror.Validation("Invalid or expired token"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to reset password", err))
+		return
+	}
+	user, err := handler.users.GetUser(ctx, token.UserID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.Validation("Invalid or expired token"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve user", err))
+		return
+	}
+	if fieldError, ok := validation.CheckPassword(user.Username, input.Password); !ok {
+		c.Error(apierror.Validation("Validation failed", fieldError))
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), handler.password.BcryptCost)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to reset password", err))
+		return
+	}
+	if _, err := handler.auth.UseAccountToken(ctx, input.Token, models.AccountTokenResetPassword); err == middlewares.ErrInvalidAccountToken {
+		// Токен успели использовать параллельным запросом
+		c.Error(apierror.Validation("Invalid or expired token"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to reset password", err))
+		return
+	}
+	if err := handler.users.SetPassword(ctx, user.ID, string(hash)); err != nil {
+		c.Error(apierror.Internal("Failed to reset password", err))
+		return
+	}
+
+	// Старым паролем могли воспользоваться, поэтому все сессии завершаются, а блокировка входа снимается
+	if err := handler.auth.RevokeUserSessions(ctx, user.ID); err != nil {
+		logging.FromContext(ctx).Error("failed to revoke sessions after password reset", "error", err)
+	}
+	if err := handler.lockout.Success(ctx, user.Username); err != nil {
+		logging.FromContext(ctx).Error("failed to reset login attempts", "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "password changed"})
+}
+
+// sendVerification отправляет пользователю письмо со ссылкой подтверждения адреса.
+// Ошибка не прерывает запрос: письмо можно запросить повторно, поэтому она только пишется в лог
+func (handler *UserHandler) sendVerification(ctx context.Context, user *models.User) {
+	token, err := handler.auth.IssueAccountToken(ctx, user.ID, models.AccountTokenVerifyEmail, user.Email, handler.account.VerificationTTL)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to issue verification token", "user_id", user.ID.Hex(), "error", err)
+		return
+	}
+
+	body := fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы подтвердить адрес почты, перейдите по ссылке:\n%s\n\nСсылка действует %s. Если вы не регистрировались, просто проигнорируйте это письмо.",
+		user.Username, accountLink(handler.account.VerifyURL, token), formatTTL(handler.account.VerificationTTL))
+	if err := handler.mailer.Send(user.Email, "Подтверждение адреса почты", body); err != nil {
+		logging.FromContext(ctx).Error("failed to send verification email", "user_id", user.ID.Hex(), "error", err)
+	}
+}
+
+// sendPasswordReset отправляет пользователю письмо со ссылкой сброса пароля. Ошибка только пишется в лог
+func (handler *UserHandler) sendPasswordReset(ctx context.Context, user *models.User) {
+	token, err := handler.auth.IssueAccountToken(ctx, user.ID, models.AccountTokenResetPassword, user.Email, handler.account.ResetTTL)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to issue password reset token", "user_id", user.ID.Hex(), "error", err)
+		return
+	}
+
+	body := fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы задать новый пароль, перейдите по ссылке:\n%s\n\nСсылка действует %s. Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.",
+		user.Username, accountLink(handler.account.ResetURL, token), formatTTL(handler.account.ResetTTL))
+	if err := handler.mailer.Send(user.Email, "Сброс пароля", body); err != nil {
+		logging.FromContext(ctx).Error("failed to send password reset email", "user_id", user.ID.Hex(), "error", err)
+	}
+}
+
+// accountLink возвращает ссылку на страницу page с токеном в параметре token. Без адреса страницы возвращает сам токен
+func accountLink(page, token string) string {
+	link, err := url.Parse(page)
+	if page == "" || err != nil {
+		return token
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// formatTTL возвращает срок действия ссылки для текста письма в часах или минутах
+func formatTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		return fmt.Sprintf("%d ч.", ttl/time.Hour)
+	}
+	return fmt.Sprintf("%d мин.", (ttl+time.Minute-1)/time.Minute)
+}
@@ -0,0 +1,436 @@
+package handlers
+
+import (
+	"context"
+	"myproject/apierror"
+	"myproject/audit"
+	"myproject/databases"
+	"myproject/logging"
+	"myproject/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ApplicationHandler struct {
+	applications databases.ApplicationStore
+	pets         databases.PetStore
+	audit        *audit.Logger
+}
+
+func CreateApplicationHandler(applications databases.ApplicationStore, pets databases.PetStore, audit *audit.Logger) *ApplicationHandler {
+	return &ApplicationHandler{applications: applications, pets: pets, audit: audit}
+}
+
+// currentUserID - вспомогательная функция, возвращающая ID пользователя, установленный middlewares.Authenticate
+func currentUserID(c *gin.Context) (primitive.ObjectID, error) {
+	return primitive.ObjectIDFromHex(c.GetString("userID"))
+}
+
+// SubmitApplication подаёт заявку на усыновление домашнего животного
+// @Summary Подать заявку на усыновление
+// @Description Создаёт заявку текущего пользователя на усыновление домашнего животного
+// @Tags Заявки
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param application body models.Application true "pet_id и сообщение для приюта"
+// @Success 201 {object} models.Application
+// @Failure 400 {object} apierror.Problem
+// @Failure 401 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /applications [post]
+func (handler *ApplicationHandler) SubmitApplication(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return
+	}
+
+	var input struct {
+		PetID   string `json:"pet_id" binding:"required,objectid"`
+		Message string `json:"message" binding:"max=2000"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	petID, err := primitive.ObjectIDFromHex(input.PetID)
+	if err != nil {
+		c.Error(apierror.Validation("Invalid pet ID"))
+		return
+	}
+
+	pet, err := handler.pets.GetPet(c.Request.Context(), petID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Pet not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve pet", err))
+		return
+	}
+	// Заявки принимаются, пока животное доступно или ожидает усыновления по другой заявке
+	if pet.Status != models.PetAvailable && pet.Status != models.PetPendingAdoption {
+		c.Error(apierror.Conflict("Pet is not available for adoption"))
+		return
+	}
+
+	// Пользователь может иметь только одну открытую заявку на одно животное
+	existing, err := handler.applications.FindApplications(c.Request.Context(), databases.ApplicationFilter{PetID: petID, UserID: userID})
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve applications", err))
+		return
+	}
+	for _, application := range existing {
+		if application.IsOpen() {
+			c.Error(apierror.Conflict("Application for this pet already submitted"))
+			return
+		}
+	}
+
+	now := time.Now()
+	application := models.Application{
+		PetID:     petID,
+		UserID:    userID,
+		Message:   input.Message,
+		Status:    models.ApplicationSubmitted,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := handler.applications.CreateApplication(c.Request.Context(), &application); err != nil {
+		c.Error(apierror.Internal("Could not submit application", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, application)
+}
+
+// GetMyApplications возвращает заявки текущего пользователя
+// @Summary Мои заявки
+// @Description Возвращает список заявок на усыновление, поданных текущим пользователем
+// @Tags Заявки
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Application
+// @Failure 401 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /applications [get]
+func (handler *ApplicationHandler) GetMyApplications(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return
+	}
+
+	applications, err := handler.applications.FindApplications(c.Request.Context(), databases.ApplicationFilter{UserID: userID})
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve applications", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, applications)
+}
+
+// WithdrawApplication отзывает заявку текущего пользователя
+// @Summary Отозвать заявку
+// @Description Отзывает заявку на усыновление, пока она не рассмотрена
+// @Tags Заявки
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID заявки"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 401 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /applications/{id}/withdraw [post]
+func (handler *ApplicationHandler) WithdrawApplication(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid application ID"))
+		return
+	}
+
+	application, err := handler.applications.GetApplication(c.Request.Context(), objectID)
+	// Чужие заявки не раскрываем и отвечаем так же, как на несуществующие
+	if err == databases.ErrNotFound || (err == nil && application.UserID != userID) {
+		c.Error(apierror.NotFound("Application not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve application", err))
+		return
+	}
+
+	if !models.CanTransitionApplication(application.Status, models.ApplicationWithdrawn) {
+		c.Error(apierror.Conflict("Application can not be withdrawn"))
+		return
+	}
+
+	err = handler.applications.UpdateApplicationStatus(c.Request.Context(), objectID, application.Status, models.ApplicationWithdrawn, "")
+	if err == databases.ErrConflict {
+		c.Error(apierror.Conflict("Application was changed, try again"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to withdraw application", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "application withdrawn"})
+}
+
+// GetApplications возвращает список заявок для администратора
+// @Summary Список заявок
+// @Description Возвращает заявки на усыновление с фильтрацией по статусу, животному и пользователю
+// @Tags Заявки
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Статус заявки"
+// @Param pet_id query string false "ID домашнего животного"
+// @Param user_id query string false "ID пользователя"
+// @Success 200 {array} models.Application
+// @Failure 400 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/applications [get]
+func (handler *ApplicationHandler) GetApplications(c *gin.Context) {
+	filter := databases.ApplicationFilter{Status: c.Query("status")}
+
+	if petID := c.Query("pet_id"); petID != "" {
+		objectID, err := primitive.ObjectIDFromHex(petID)
+		if err != nil {
+			c.Error(apierror.Validation("Invalid pet ID"))
+			return
+		}
+		filter.PetID = objectID
+	}
+
+	if userID := c.Query("user_id"); userID != "" {
+		objectID, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			c.Error(apierror.Validation("Invalid user ID"))
+			return
+		}
+		filter.UserID = objectID
+	}
+
+	applications, err := handler.applications.FindApplications(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve applications", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, applications)
+}
+
+// GetApplication возвращает заявку по ID для администратора
+// @Summary Получение заявки
+// @Description Возвращает заявку на усыновление по ID
+// @Tags Заявки
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID заявки"
+// @Success 200 {object} models.Application
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/applications/{id} [get]
+func (handler *ApplicationHandler) GetApplication(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid application ID"))
+		return
+	}
+
+	application, err := handler.applications.GetApplication(c.Request.Context(), objectID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Application not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve application", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, application)
+}
+
+// ReviewApplication меняет статус заявки
+// @Summary Рассмотрение заявки
+// @Description Переводит заявку в статус under_review, approved или rejected. При одобрении животное помечается усыновлённым, а остальные заявки на него отклоняются; если какой-то из этих шагов не удался, заявка и животное возвращаются в прежнее состояние. Сотрудник приюта может рассматривать только заявки на животных своего приюта
+// @Tags Заявки
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID заявки"
+// @Param review body object true "Новый статус и комментарий"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/applications/{id}/status [put]
+func (handler *ApplicationHandler) ReviewApplication(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid application ID"))
+		return
+	}
+
+	var input struct {
+		Status  string `json:"status" binding:"required,oneof=under_review approved rejected withdrawn"`
+		Comment string `json:"comment" binding:"max=2000"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	// Отзыв заявки доступен только её автору
+	if input.Status == models.ApplicationWithdrawn {
+		c.Error(apierror.Validation("Only the applicant can withdraw an application"))
+		return
+	}
+
+	application, err := handler.applications.GetApplication(c.Request.Context(), objectID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Application not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve application", err))
+		return
+	}
+
+	// Сотрудник приюта рассматривает только заявки на животных своего приюта
+	pet, err := handler.pets.GetPet(c.Request.Context(), application.PetID)
+	if err != nil && err != databases.ErrNotFound {
+		c.Error(apierror.Internal("Failed to retrieve pet", err))
+		return
+	}
+	if (pet == nil && c.GetString("shelterID") != "") || (pet != nil && !canManagePet(c, pet)) {
+		c.Error(apierror.Forbidden("Access forbidden"))
+		return
+	}
+
+	if !models.CanTransitionApplication(application.Status, input.Status) {
+		c.Error(apierror.Conflict("Invalid status transition from " + application.Status + " to " + input.Status))
+		return
+	}
+
+	// Одобрить заявку можно, только если животное может перейти в статус adopted
+	if input.Status == models.ApplicationApproved {
+		if pet == nil {
+			c.Error(apierror.Conflict("Pet not found"))
+			return
+		}
+		if !models.CanTransitionPet(pet.Status, models.PetAdopted) {
+			c.Error(apierror.Conflict("Pet can not be adopted from status " + pet.Status))
+			return
+		}
+
+		if !handler.approveApplication(c, application, pet, input.Comment) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "application " + input.Status})
+		return
+	}
+
+	err = handler.applications.UpdateApplicationStatus(c.Request.Context(), objectID, application.Status, input.Status, input.Comment)
+	if err == databases.ErrConflict {
+		c.Error(apierror.Conflict("Application was changed, try again"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to update application", err))
+		return
+	}
+	handler.audit.Record(c, "application.review", "application", objectID.Hex(),
+		gin.H{"status": application.Status, "comment": application.Comment},
+		gin.H{"status": input.Status, "comment": input.Comment})
+
+	c.JSON(http.StatusOK, gin.H{"status": "application " + input.Status})
+}
+
+// approveApplication одобряет заявку, помечает животное усыновлённым и отклоняет остальные заявки на него.
+// Если какой-то шаг не удался, выполненные шаги отменяются, чтобы заявка не осталась одобренной
+// при неусыновлённом животном. Ошибка передаётся в gin через c.Error
+func (handler *ApplicationHandler) approveApplication(c *gin.Context, application *models.Application, pet *models.Pet, comment string) bool {
+	ctx := c.Request.Context()
+	// Отмена выполняется, даже если клиент разорвал соединение
+	rollbackCtx := context.WithoutCancel(ctx)
+
+	err := handler.applications.UpdateApplicationStatus(ctx, application.ID, application.Status, models.ApplicationApproved, comment)
+	if err == databases.ErrConflict {
+		c.Error(apierror.Conflict("Application was changed, try again"))
+		return false
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to update application", err))
+		return false
+	}
+
+	reviewerID, _ := currentUserID(c)
+	change := models.PetStatusChange{
+		PetID:     pet.ID,
+		From:      pet.Status,
+		To:        models.PetAdopted,
+		Reason:    "Application " + application.ID.Hex() + " approved",
+		ChangedBy: reviewerID,
+		ChangedAt: time.Now(),
+	}
+	err = handler.pets.SetPetStatus(ctx, &change)
+	if err != nil {
+		handler.revertApproval(rollbackCtx, application)
+		if err == databases.ErrConflict {
+			c.Error(apierror.Conflict("Pet status was changed, try again"))
+		} else {
+			c.Error(apierror.Internal("Failed to mark pet as adopted", err))
+		}
+		return false
+	}
+
+	err = handler.applications.CloseApplicationsForPet(ctx, application.PetID, application.ID, "Pet has been adopted")
+	if err != nil {
+		handler.revertAdoption(rollbackCtx, &change)
+		handler.revertApproval(rollbackCtx, application)
+		c.Error(apierror.Internal("Failed to close competing applications", err))
+		return false
+	}
+
+	handler.audit.Record(c, "application.review", "application", application.ID.Hex(),
+		gin.H{"status": application.Status, "comment": application.Comment},
+		gin.H{"status": models.ApplicationApproved, "comment": comment})
+	handler.audit.Record(c, "pet.status", "pet", pet.ID.Hex(),
+		gin.H{"status": change.From}, gin.H{"status": change.To, "reason": change.Reason})
+	return true
+}
+
+// revertApproval возвращает одобренную заявку в прежний статус. Ошибка только пишется в лог
+func (handler *ApplicationHandler) revertApproval(ctx context.Context, application *models.Application) {
+	err := handler.applications.UpdateApplicationStatus(ctx, application.ID, models.ApplicationApproved, application.Status, application.Comment)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to revert application approval", "application_id", application.ID.Hex(), "error", err)
+	}
+}
+
+// revertAdoption возвращает животное в статус, из которого оно было переведено изменением adoption.
+// Отмена записывается в историю статусов. Ошибка только пишется в лог
+func (handler *ApplicationHandler) revertAdoption(ctx context.Context, adoption *models.PetStatusChange) {
+	change := models.PetStatusChange{
+		PetID:     adoption.PetID,
+		From:      adoption.To,
+		To:        adoption.From,
+		Reason:    "Adoption rolled back: " + adoption.Reason,
+		ChangedBy: adoption.ChangedBy,
+		ChangedAt: time.Now(),
+	}
+	if err := handler.pets.SetPetStatus(ctx, &change); err != nil {
+		logging.FromContext(ctx).Error("failed to revert pet adoption", "pet_id", adoption.PetID.Hex(), "error", err)
+	}
+}
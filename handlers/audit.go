@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"myproject/apierror"
+	"myproject/databases"
+	"myproject/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// defaultAuditLimit и maxAuditLimit - число записей журнала аудита в ответе по умолчанию и максимальное
+	defaultAuditLimit = 100
+	maxAuditLimit     = 10000
+)
+
+type AuditHandler struct {
+	audit databases.AuditStore
+}
+
+func CreateAuditHandler(audit databases.AuditStore) *AuditHandler {
+	return &AuditHandler{audit: audit}
+}
+
+// GetAudit возвращает записи журнала аудита
+// @Summary Журнал аудита
+// @Description Возвращает записи журнала изменений, выполненных через административные маршруты, начиная с последних. С параметром format=csv журнал выгружается в CSV
+// @Tags Аудит
+// @Produce json
+// @Produce text/csv
+// @Security BearerAuth
+// @Param actor query string false "ID пользователя, выполнившего действие"
+// @Param entity query string false "Тип сущности: pet, shelter, application, role или user"
+// @Param entity_id query string false "ID сущности"
+// @Param action query string false "Действие, например pet.update"
+// @Param from query string false "Начало интервала времени в формате RFC 3339"
+// @Param to query string false "Конец интервала времени в формате RFC 3339 (не включается)"
+// @Param limit query int false "Число записей (от 1 до 10000, по умолчанию 100)"
+// @Param format query string false "Формат ответа: json (по умолчанию) или csv"
+// @Success 200 {array} models.AuditEntry
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/audit [get]
+func (handler *AuditHandler) GetAudit(c *gin.Context) {
+	filter := databases.AuditFilter{
+		Entity:   c.Query("entity"),
+		EntityID: c.Query("entity_id"),
+		Action:   c.Query("action"),
+	}
+
+	if actor := c.Query("actor"); actor != "" {
+		actorID, err := primitive.ObjectIDFromHex(actor)
+		if err != nil {
+			c.Error(apierror.Validation("Invalid actor ID"))
+			return
+		}
+		filter.ActorID = actorID
+	}
+
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		c.Error(apierror.Validation("Invalid from"))
+		return
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		c.Error(apierror.Validation("Invalid to"))
+		return
+	}
+
+	limit := defaultAuditLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			c.Error(apierror.Validation("Invalid limit"))
+			return
+		}
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.Error(apierror.Validation("Invalid format"))
+		return
+	}
+
+	entries, err := handler.audit.FindAudit(c.Request.Context(), filter, limit)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve audit log", err))
+		return
+	}
+
+	if format == "csv" {
+		writeAuditCSV(c, entries)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// writeAuditCSV - вспомогательная функция, отправляющая записи журнала аудита в формате CSV.
+// Изменения записываются в последний столбец в формате JSON
+func writeAuditCSV(c *gin.Context, entries []models.AuditEntry) {
+	c.Header("Content-Disposition", `attachment; filename="audit.csv"`)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"timestamp", "actor_id", "actor_role", "action", "entity", "entity_id", "ip", "changes"})
+	for _, entry := range entries {
+		changes, _ := json.Marshal(entry.Changes)
+		writer.Write([]string{
+			entry.Timestamp.UTC().Format(time.RFC3339),
+			entry.ActorID.Hex(),
+			entry.ActorRole,
+			entry.Action,
+			entry.Entity,
+			entry.EntityID,
+			entry.IP,
+			string(changes),
+		})
+	}
+	writer.Flush()
+}
+
+// parseTimeQuery - вспомогательная функция, разбирающая необязательный параметр запроса в формате RFC 3339
+func parseTimeQuery(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"myproject/apierror"
+	"myproject/databases"
+	"myproject/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetFavorites возвращает избранных домашних животных текущего пользователя
+// @Summary Избранное
+// @Description Возвращает актуальные данные домашних животных из избранного текущего пользователя, начиная с последних добавленных
+// @Tags Избранное
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Pet
+// @Failure 401 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /favorites [get]
+func (handler *PetHandler) GetFavorites(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return
+	}
+
+	favorites, err := handler.favorites.FindFavorites(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve favorites", err))
+		return
+	}
+
+	petIDs := make([]primitive.ObjectID, len(favorites))
+	for i, favorite := range favorites {
+		petIDs[i] = favorite.PetID
+	}
+	found, err := handler.pets.GetPets(c.Request.Context(), petIDs)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve pets", err))
+		return
+	}
+
+	pets := []models.Pet{}
+	for _, favorite := range favorites {
+		// Животное могло быть удалено между запросами
+		if pet, ok := found[favorite.PetID]; ok {
+			pets = append(pets, handler.withPhotoURLs(pet))
+		}
+	}
+
+	if !handler.withFavorites(c, pets) {
+		return
+	}
+
+	c.JSON(http.StatusOK, pets)
+}
+
+// AddFavorite добавляет домашнее животное в избранное текущего пользователя
+// @Summary Добавление в избранное
+// @Description Добавляет домашнее животное в избранное текущего пользователя. Повторное добавление не считается ошибкой
+// @Tags Избранное
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID домашнего животного"
+// @Success 200 {object} models.Favorite
+// @Failure 400 {object} apierror.Problem
+// @Failure 401 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /favorites/{id} [put]
+func (handler *PetHandler) AddFavorite(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return
+	}
+
+	petID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid pet ID"))
+		return
+	}
+
+	_, err = handler.pets.GetPet(c.Request.Context(), petID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Pet not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve pet", err))
+		return
+	}
+
+	favorite := models.Favorite{UserID: userID, PetID: petID, CreatedAt: time.Now()}
+	if err := handler.favorites.AddFavorite(c.Request.Context(), &favorite); err != nil {
+		c.Error(apierror.Internal("Failed to add favorite", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, favorite)
+}
+
+// RemoveFavorite удаляет домашнее животное из избранного текущего пользователя
+// @Summary Удаление из избранного
+// @Description Удаляет домашнее животное из избранного текущего пользователя
+// @Tags Избранное
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID домашнего животного"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 401 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /favorites/{id} [delete]
+func (handler *PetHandler) RemoveFavorite(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return
+	}
+
+	petID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid pet ID"))
+		return
+	}
+
+	err = handler.favorites.RemoveFavorite(c.Request.Context(), userID, petID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Favorite not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to remove favorite", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "favorite removed"})
+}
+
+// withFavorites - вспомогательная функция, заполняющая у животных число добавлений в избранное и,
+// если пользователь аутентифицирован, признак нахождения в его избранном.
+// Возвращает false, если ошибка уже передана в gin через c.Error
+func (handler *PetHandler) withFavorites(c *gin.Context, pets []models.Pet) bool {
+	if len(pets) == 0 {
+		return true
+	}
+
+	petIDs := make([]primitive.ObjectID, len(pets))
+	for i, pet := range pets {
+		petIDs[i] = pet.ID
+	}
+
+	counts, err := handler.favorites.CountFavorites(c.Request.Context(), petIDs)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve favorites", err))
+		return false
+	}
+
+	favorited := map[primitive.ObjectID]bool{}
+	if userID, err := currentUserID(c); err == nil {
+		favorited, err = handler.favorites.FavoritePetIDs(c.Request.Context(), userID, petIDs)
+		if err != nil {
+			c.Error(apierror.Internal("Failed to retrieve favorites", err))
+			return false
+		}
+	}
+
+	for i := range pets {
+		pets[i].FavoriteCount = counts[pets[i].ID]
+		pets[i].Favorited = favorited[pets[i].ID]
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout - время ожидания ответа одной зависимости при проверке готовности
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck - проверка доступности зависимости сервиса, например базы данных
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// DependencyStatus - состояние одной зависимости сервиса
+type DependencyStatus struct {
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ReadinessResponse - результат проверки готовности сервиса
+type ReadinessResponse struct {
+	Status string                      `json:"status"`
+	Checks map[string]DependencyStatus `json:"checks"`
+}
+
+type HealthHandler struct {
+	checks []HealthCheck
+}
+
+func CreateHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Liveness сообщает, что процесс запущен и обрабатывает запросы. Зависимости не проверяются.
+// Служебные маршруты не входят в версии API и не описываются в документации swagger
+func (handler *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness проверяет доступность всех зависимостей сервиса и возвращает состояние каждой.
+// Если хотя бы одна зависимость недоступна, отвечает 503
+func (handler *HealthHandler) Readiness(c *gin.Context) {
+	response := ReadinessResponse{Status: "ok", Checks: map[string]DependencyStatus{}}
+	status := http.StatusOK
+
+	for _, check := range handler.checks {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+		started := time.Now()
+		err := check.Check(ctx)
+		cancel()
+
+		dependency := DependencyStatus{Status: "ok", DurationMS: time.Since(started).Milliseconds()}
+		if err != nil {
+			dependency.Status = "unavailable"
+			dependency.Error = err.Error()
+			response.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+		response.Checks[check.Name] = dependency
+	}
+
+	c.JSON(status, response)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"myproject/databases"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPageLimit - максимальный размер страницы, который может запросить клиент
+const maxPageLimit = 100
+
+// parsePageRequest разбирает параметры постраничной выдачи: page и limit для выдачи по номерам страниц,
+// cursor для выдачи по курсору и sort в виде "поле" или "-поле" для сортировки по убыванию.
+// Возвращает также номер запрошенной страницы (начиная с 1)
+func parsePageRequest(c *gin.Context, isSort func(string) bool) (databases.PageRequest, int, error) {
+	request := databases.PageRequest{Limit: databases.DefaultPageLimit, Cursor: c.Query("cursor")}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > maxPageLimit {
+			return request, 0, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		request.Limit = value
+	}
+
+	page := 1
+	if value := c.Query("page"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			return request, 0, errors.New("page must be a positive number")
+		}
+		// Смещение (page - 1) * limit не должно переполнять int
+		if number-1 > math.MaxInt/request.Limit {
+			return request, 0, errors.New("page is too large")
+		}
+		page = number
+	}
+	if request.Cursor == "" {
+		request.Offset = (page - 1) * request.Limit
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		request.Desc = strings.HasPrefix(sort, "-")
+		request.Sort = strings.TrimPrefix(sort, "-")
+		if !isSort(request.Sort) {
+			return request, 0, errors.New("unsupported sort field " + request.Sort)
+		}
+	}
+
+	return request, page, nil
+}
+
+// setLinkHeader добавляет заголовок Link (RFC 8288) со ссылками на соседние страницы.
+// При выдаче по курсору доступна только ссылка на следующую страницу
+func setLinkHeader(c *gin.Context, request databases.PageRequest, page int, total int64, nextCursor string) {
+	link := func(rel string, change func(query url.Values)) string {
+		target := *c.Request.URL
+		query := target.Query()
+		change(query)
+		target.RawQuery = query.Encode()
+		return fmt.Sprintf("<%s>; rel=\"%s\"", target.RequestURI(), rel)
+	}
+	setPage := func(number int) func(url.Values) {
+		return func(query url.Values) {
+			query.Del("cursor")
+			query.Set("page", strconv.Itoa(number))
+			query.Set("limit", strconv.Itoa(request.Limit))
+		}
+	}
+
+	var links []string
+	if nextCursor != "" {
+		links = append(links, link("next", func(query url.Values) {
+			query.Del("page")
+			query.Set("cursor", nextCursor)
+			query.Set("limit", strconv.Itoa(request.Limit))
+		}))
+	}
+
+	if request.Cursor == "" {
+		lastPage := max(1, int((total+int64(request.Limit)-1)/int64(request.Limit)))
+		links = append(links, link("first", setPage(1)), link("last", setPage(lastPage)))
+		if page > 1 {
+			links = append(links, link("prev", setPage(min(page-1, lastPage))))
+		}
+	}
+
+	// Заголовок Link может уже содержать ссылку на замену устаревшего маршрута
+	if len(links) > 0 {
+		c.Writer.Header().Add("Link", strings.Join(links, ", "))
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"myproject/apierror"
+	"myproject/databases"
+	"myproject/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChangePetStatus переводит домашнее животное в новый статус
+// @Summary Изменение статуса домашнего животного
+// @Description Переводит домашнее животное в новый статус с указанием причины. Допустимые переходы: intake -> available, on_hold, deceased; available -> on_hold, pending_adoption, adopted, deceased; on_hold -> available, deceased; pending_adoption -> available, on_hold, adopted, deceased; adopted -> returned; returned -> intake, available, on_hold, deceased. Сотрудник приюта может изменять только животных своего приюта
+// @Tags Домашние животные
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID домашнего животного"
+// @Param status body object true "status и reason"
+// @Success 200 {object} models.PetStatusChange
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/pets/{id}/status [post]
+func (handler *PetHandler) ChangePetStatus(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid pet ID"))
+		return
+	}
+
+	var input struct {
+		Status string `json:"status" binding:"required,pet_status"`
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	input.Reason = strings.TrimSpace(input.Reason)
+	if input.Reason == "" {
+		c.Error(apierror.Validation("Reason required"))
+		return
+	}
+
+	pet, ok := handler.getManagedPet(c, objectID)
+	if !ok {
+		return
+	}
+
+	if !models.CanTransitionPet(pet.Status, input.Status) {
+		c.Error(apierror.Conflict("Invalid status transition from " + pet.Status + " to " + input.Status))
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	change := models.PetStatusChange{
+		PetID:     objectID,
+		From:      pet.Status,
+		To:        input.Status,
+		Reason:    input.Reason,
+		ChangedBy: userID,
+		ChangedAt: time.Now(),
+	}
+	err = handler.pets.SetPetStatus(c.Request.Context(), &change)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Pet not found"))
+		return
+	} else if err == databases.ErrConflict {
+		c.Error(apierror.Conflict("Pet status was changed, try again"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to update pet status", err))
+		return
+	}
+	handler.audit.Record(c, "pet.status", "pet", objectID.Hex(),
+		gin.H{"status": change.From}, gin.H{"status": change.To, "reason": change.Reason})
+	handler.matcher.PetChanged(objectID)
+
+	c.JSON(http.StatusOK, change)
+}
+
+// GetPetStatusHistory возвращает историю статусов домашнего животного
+// @Summary История статусов домашнего животного
+// @Description Возвращает все переходы статуса домашнего животного в хронологическом порядке. Сотрудник приюта может просматривать только животных своего приюта
+// @Tags Домашние животные
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID домашнего животного"
+// @Success 200 {array} models.PetStatusChange
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/pets/{id}/history [get]
+func (handler *PetHandler) GetPetStatusHistory(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid pet ID"))
+		return
+	}
+
+	if _, ok := handler.getManagedPet(c, objectID); !ok {
+		return
+	}
+
+	changes, err := handler.pets.FindPetStatusChanges(c.Request.Context(), objectID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve status history", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
@@ -1,258 +1,367 @@
 package handlers
 
 import (
-	"context"
+	"myproject/apierror"
+	"myproject/audit"
+	"myproject/config"
 	"myproject/databases"
+	"myproject/logging"
+	"myproject/media"
 	"myproject/models"
+	"myproject/notifications"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// PetListResponse - страница списка домашних животных
+type PetListResponse struct {
+	Items []models.Pet `json:"items"`
+	Total int64        `json:"total"`
+	Limit int          `json:"limit"`
+	// Page - номер страницы, не заполняется при выдаче по курсору
+	Page       int    `json:"page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
 type PetHandler struct {
-	database *databases.MongoDB
+	pets      databases.PetStore
+	shelters  databases.ShelterStore
+	favorites databases.FavoriteStore
+	matcher   *notifications.Matcher
+	audit     *audit.Logger
+	blobs     media.BlobStore
+	config    config.MediaConfig
 }
 
-func CreatePetHandler(database *databases.MongoDB) *PetHandler {
-	return &PetHandler{database: database}
+func CreatePetHandler(pets databases.PetStore, shelters databases.ShelterStore, favorites databases.FavoriteStore, matcher *notifications.Matcher, audit *audit.Logger, blobs media.BlobStore, config config.MediaConfig) *PetHandler {
+	return &PetHandler{pets: pets, shelters: shelters, favorites: favorites, matcher: matcher, audit: audit, blobs: blobs, config: config}
+}
+
+// canManagePet проверяет, может ли текущий пользователь изменять домашнее животное. Право на само действие
+// проверяет middlewares.RequirePermission, здесь проверяется только область: пользователь, привязанный
+// к приюту (сотрудник приюта), управляет только животными своего приюта, остальные - всеми животными
+func canManagePet(c *gin.Context, pet *models.Pet) bool {
+	shelterID := c.GetString("shelterID")
+	return shelterID == "" || shelterID == pet.ShelterID.Hex()
+}
+
+// checkShelter - вспомогательная функция, проверяющая существование приюта, к которому привязывается животное.
+// Возвращает false, если ошибка уже передана в gin через c.Error
+func (handler *PetHandler) checkShelter(c *gin.Context, shelterID primitive.ObjectID) bool {
+	if shelterID.IsZero() {
+		c.Error(apierror.Validation("Shelter ID required"))
+		return false
+	}
+
+	_, err := handler.shelters.GetShelter(c.Request.Context(), shelterID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.Validation("Shelter not found"))
+		return false
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve shelter", err))
+		return false
+	}
+
+	return true
 }
 
 // GetPet получает информацию о домашнем животном по ID
 // @Summary Получение домашнего животного
-// @Description Возвращает информацию о домашнем животном по ID
+// @Description Возвращает информацию о домашнем животном по ID. Если запрос содержит токен, заполняется признак favorited
 // @Tags Домашние животные
 // @Accept json
 // @Produce json
 // @Param id path string true "ID домашнего животного"
 // @Success 200 {object} models.Pet
-// @Failure 404 {object} map[string]string "error"
-// @Failure 500 {object} map[string]string "error"
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
 // @Router /pets/{id} [get]
 func (handler *PetHandler) GetPet(c *gin.Context) {
-	id := c.Param("id")
-	collection := handler.database.Collection("pets")
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid pet ID"))
+		return
+	}
 
-	var pet models.Pet
-	err := collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&pet)
-	if err == mongo.ErrNoDocuments {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Pet not found"})
+	pet, err := handler.pets.GetPet(c.Request.Context(), objectID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Pet not found"))
 		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve pet"})
+		c.Error(apierror.Internal("Failed to retrieve pet", err))
+		return
+	}
+
+	pets := []models.Pet{handler.withPhotoURLs(*pet)}
+	if !handler.withFavorites(c, pets) {
 		return
 	}
 
-	c.JSON(http.StatusOK, pet)
+	c.JSON(http.StatusOK, pets[0])
 }
 
 // CreatePet добавляет нового питомца в базу данных
 // @Summary Создать новое домажнее животное
-// @Description создает новое домашнее животное в системе
+// @Description создает новое домашнее животное в системе. Сотрудник приюта может добавлять животных только в свой приют. Начальный статус - intake или available (по умолчанию)
 // @Tags Домашние животные
 // @Accept  json
 // @Produce  json
+// @Security BearerAuth
 // @Param pet body models.Pet true "Информация о питомце"
 // @Success 200 {object} map[string]string "status"
-// @Failure 400 {object} map[string]string "error"
-// @Failure 500 {object} map[string]string "error"
-// @Router /pets [post]
+// @Failure 400 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/pets [post]
 func (handler *PetHandler) CreatePet(c *gin.Context) {
 	var pet models.Pet
-	if err := c.ShouldBindJSON(&pet); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &pet) {
+		return
+	}
+
+	// Фотографии загружаются отдельным запросом, а в корзину животное попадает только при удалении
+	pet.Photos = nil
+	pet.DeletedAt = nil
+	pet.DeletedBy = nil
+	pet.CreatedAt = time.Now()
+
+	if pet.Status == "" {
+		pet.Status = models.PetAvailable
+	}
+	if pet.Status != models.PetIntake && pet.Status != models.PetAvailable {
+		c.Error(apierror.Validation("Initial status must be intake or available"))
+		return
+	}
+
+	// Сотрудник приюта всегда добавляет животное в свой приют
+	if c.GetString("shelterID") != "" {
+		shelterID, err := primitive.ObjectIDFromHex(c.GetString("shelterID"))
+		if err != nil {
+			c.Error(apierror.Forbidden("Access forbidden"))
+			return
+		}
+		pet.ShelterID = shelterID
+	}
+	if !handler.checkShelter(c, pet.ShelterID) {
 		return
 	}
 
-	collection := handler.database.Collection("pets")
-	_, err := collection.InsertOne(context.TODO(), pet)
+	err := handler.pets.CreatePet(c.Request.Context(), &pet)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create pet"})
+		c.Error(apierror.Internal("Could not create pet", err))
 		return
 	}
 
+	// История статусов начинается с поступления животного
+	userID, _ := currentUserID(c)
+	change := models.PetStatusChange{PetID: pet.ID, To: pet.Status, Reason: "Pet created", ChangedBy: userID, ChangedAt: pet.CreatedAt}
+	if err := handler.pets.AddPetStatusChange(c.Request.Context(), &change); err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to record initial pet status", "pet_id", pet.ID.Hex(), "error", err)
+	}
+	handler.audit.Record(c, "pet.create", "pet", pet.ID.Hex(), nil, pet)
+	handler.matcher.PetChanged(pet.ID)
+
 	c.JSON(http.StatusOK, gin.H{"status": "pet created"})
 }
 
 // GetPets получает список домашних животных по заданным параметрам
 // @Summary Получение списка домашних животных
-// @Description Возвращает список домашних животных по заданным параметрам фильтрации
+// @Description Возвращает страницу списка домашних животных по заданным параметрам фильтрации и сортировки. Если запрос содержит токен, заполняется признак favorited
 // @Tags Домашние животные
 // @Accept json
 // @Produce json
-// @Param petid query int false "ID домашнего животного"
-// @Param name query string false "Имя домашнего животного"
-// @Param age query int false "Возраст"
+// @Param shelter_id query string false "ID приюта"
+// @Param name query string false "Начало имени домашнего животного (без учёта регистра)"
+// @Param age query int false "Точный возраст"
+// @Param age_min query int false "Минимальный возраст"
+// @Param age_max query int false "Максимальный возраст"
 // @Param gender query string false "Пол"
-// @Param species query string false "Вид домашнего животного"
-// @Param breed query string false "Порода"
-// @Success 200 {array} models.Pet
-// @Failure 500 {object} map[string]string "error"
+// @Param species query string false "Виды домашних животных через запятую"
+// @Param breed query string false "Породы через запятую"
+// @Param q query string false "Полнотекстовый поиск по имени, породе и описанию"
+// @Param status query string false "Статусы через запятую, по умолчанию available"
+// @Param page query int false "Номер страницы (начиная с 1)"
+// @Param limit query int false "Размер страницы (от 1 до 100, по умолчанию 20)"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Param sort query string false "Поле сортировки: name, age или created_at; префикс - для сортировки по убыванию"
+// @Success 200 {object} PetListResponse
+// @Header 200 {string} Link "Ссылки на следующую, первую, последнюю и предыдущую страницы"
+// @Failure 400 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
 // @Router /pets [get]
 func (handler *PetHandler) GetPets(c *gin.Context) {
-	collection := handler.database.Collection("pets")
-
-	// Построение фильтра на основе параметров запроса
-	filter := bson.M{}
-
-	// Получаем параметры запроса (они могут быть пустыми)
-	petid := c.Query("id")
-	name := c.Query("name")
-	age := c.Query("age")
-	gender := c.Query("gender")
-	species := c.Query("species")
-	breed := c.Query("breed")
-
-	if petid != "" {
-		filter["id"] = petid
-	}
-
-	if name != "" {
-		filter["name"] = name
+	filter, err := parsePetFilter(c)
+	if err != nil {
+		c.Error(apierror.Validation(err.Error()))
+		return
 	}
 
-	if age != "" {
-		filter["age"] = age
+	pageRequest, page, err := parsePageRequest(c, databases.IsPetSort)
+	if err != nil {
+		c.Error(apierror.Validation(err.Error()))
+		return
 	}
 
-	if gender != "" {
-		filter["gender"] = gender
+	// Выполняем поиск в хранилище
+	result, err := handler.pets.FindPets(c.Request.Context(), filter, pageRequest)
+	if err == databases.ErrInvalidCursor {
+		c.Error(apierror.Validation("Invalid cursor"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve pets", err))
+		return
 	}
 
-	if species != "" {
-		filter["species"] = species
+	response := PetListResponse{
+		Items:      make([]models.Pet, len(result.Pets)),
+		Total:      result.Total,
+		Limit:      pageRequest.Limit,
+		NextCursor: result.NextCursor,
 	}
-
-	if breed != "" {
-		filter["breed"] = breed
+	for i, pet := range result.Pets {
+		response.Items[i] = handler.withPhotoURLs(pet)
 	}
-
-	// Выполняем поиск в базе данных
-	cursor, err := collection.Find(context.TODO(), filter)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve pets"})
+	if !handler.withFavorites(c, response.Items) {
 		return
 	}
-	defer cursor.Close(context.TODO())
-
-	// Создаем слайс для хранения пользователей
-	var pets []models.Pet
-	for cursor.Next(context.TODO()) {
-		var pet models.Pet
-		if err := cursor.Decode(&pet); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode pet"})
-			return
-		}
-		pets = append(pets, pet)
+	if pageRequest.Cursor == "" {
+		response.Page = page
 	}
 
-	if err := cursor.Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cursor error"})
-		return
-	}
-
-	// Возвращаем список пользователей
-	c.JSON(http.StatusOK, pets)
+	// Возвращаем страницу списка домашних животных
+	setLinkHeader(c, pageRequest, page, result.Total, result.NextCursor)
+	c.JSON(http.StatusOK, response)
 }
 
 // UpdatePet обновляет данные домашнего животного
 // @Summary Обновление данных домашнего животного
-// @Description Обновляет данные домашнего животного по ID
+// @Description Обновляет данные домашнего животного по ID. Сотрудник приюта может изменять только животных своего приюта. Статус изменяется отдельным запросом
 // @Tags Домашние животные
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path string true "ID домашнего животного"
 // @Param pet body models.Pet true "Новые данные домашнего животного"
 // @Success 200 {object} map[string]string "status"
-// @Failure 400 {object} map[string]string "error"
-// @Failure 404 {object} map[string]string "error"
-// @Failure 500 {object} map[string]string "error"
-// @Router /pets/{id} [put]
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/pets/{id} [put]
 func (handler *PetHandler) UpdatePet(c *gin.Context) {
 	id := c.Param("id")
 
 	// Преобразование id из строки в ObjectID
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		c.Error(apierror.Validation("Invalid pet ID"))
 		return
 	}
 
 	var pet models.Pet
-	if err := c.ShouldBindJSON(&pet); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &pet) {
 		return
 	}
 
-	// Фильтр для поиска домашнего животного по ID
-	filter := bson.M{"_id": objectID}
-
-	// Данные для обновления
-	update := bson.M{
-		"$set": bson.M{
-			"name":    pet.Name,
-			"age":     pet.Age,
-			"gender":  pet.Gender,
-			"species": pet.Species,
-			"breed":   pet.Breed,
-		},
+	current, ok := handler.getManagedPet(c, objectID)
+	if !ok {
+		return
 	}
 
-	collection := handler.database.Collection("pets")
-	result, err := collection.UpdateOne(context.TODO(), filter, update)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	// Переводить животное в другой приют может только пользователь, не привязанный к приюту
+	if pet.ShelterID.IsZero() || c.GetString("shelterID") != "" {
+		pet.ShelterID = current.ShelterID
+	} else if !handler.checkShelter(c, pet.ShelterID) {
 		return
 	}
 
-	// Проверяем, был ли найден и обновлен пользователь
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Pet not found"})
+	err = handler.pets.UpdatePet(c.Request.Context(), objectID, &pet)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Pet not found"))
 		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to update pet", err))
+		return
+	}
+	if updated, err := handler.pets.GetPet(c.Request.Context(), objectID); err == nil {
+		handler.audit.Record(c, "pet.update", "pet", objectID.Hex(), current, updated)
 	}
+	handler.matcher.PetChanged(objectID)
 
 	c.JSON(http.StatusOK, gin.H{"status": "pet updated"})
 }
 
-// DeletePet удаляет домашнее животное из базы данных по ID
+// DeletePet перемещает домашнее животное в корзину по ID
 // @Summary Удаление домашнего животного
-// @Description Удаляет домашнее животное по ID
+// @Description Перемещает домашнее животное в корзину, откуда его можно восстановить до окончательного удаления по истечении срока хранения. Сотрудник приюта может удалять только животных своего приюта
 // @Tags Домашние животные
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path string true "ID домашнего животного"
 // @Success 200 {object} map[string]string "status"
-// @Failure 400 {object} map[string]string "error"
-// @Failure 404 {object} map[string]string "error"
-// @Failure 500 {object} map[string]string "error"
-// @Router /pets/{id} [delete]
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/pets/{id} [delete]
 func (handler *PetHandler) DeletePet(c *gin.Context) {
 	id := c.Param("id")
 
 	// Преобразование строки в ObjectID
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pet ID"})
+		c.Error(apierror.Validation("Invalid pet ID"))
 		return
 	}
 
-	// Фильтр для удаления по _id
-	filter := bson.M{"_id": objectID}
-
-	collection := handler.database.Collection("pets")
-	result, err := collection.DeleteOne(context.TODO(), filter)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete pet"})
+	pet, ok := handler.getManagedPet(c, objectID)
+	if !ok {
 		return
 	}
 
-	// Если домашнее животное не найдено
-	if result.DeletedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Pet not found"})
+	// Фотографии и избранное сохраняются до окончательного удаления, чтобы животное можно было восстановить
+	userID, _ := currentUserID(c)
+	err = handler.pets.DeletePet(c.Request.Context(), objectID, userID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Pet not found"))
 		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to delete pet", err))
+		return
+	}
+
+	if deleted, err := handler.pets.GetDeletedPet(c.Request.Context(), objectID); err == nil {
+		handler.audit.Record(c, "pet.delete", "pet", objectID.Hex(), pet, deleted)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "pet deleted"})
+	c.JSON(http.StatusOK, gin.H{"status": "pet moved to trash"})
+}
+
+// getManagedPet - вспомогательная функция, загружающая животное и проверяющая права текущего пользователя на него.
+// Возвращает false, если ошибка уже передана в gin через c.Error
+func (handler *PetHandler) getManagedPet(c *gin.Context, id primitive.ObjectID) (*models.Pet, bool) {
+	pet, err := handler.pets.GetPet(c.Request.Context(), id)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Pet not found"))
+		return nil, false
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve pet", err))
+		return nil, false
+	}
+
+	if !canManagePet(c, pet) {
+		c.Error(apierror.Forbidden("Access forbidden"))
+		return nil, false
+	}
+
+	return pet, true
+}
+
+// parsePetFilter - вспомогательная функция, строящая фильтр поиска на основе параметров запроса (они могут быть пустыми)
+func parsePetFilter(c *gin.Context) (databases.PetFilter, error) {
+	return databases.ParsePetFilter(c.Request.URL.Query())
 }
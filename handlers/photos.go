@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"myproject/apierror"
+	"myproject/databases"
+	"myproject/media"
+	"myproject/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// photoExtensions - допустимые типы изображений и расширения, под которыми они сохраняются
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// multipartOverhead - запас на заголовки и границы multipart-запроса сверх размера самой фотографии
+const multipartOverhead = 64 << 10
+
+// withPhotoURLs возвращает копию животного с заполненными адресами фотографий
+func (handler *PetHandler) withPhotoURLs(pet models.Pet) models.Pet {
+	photos := make([]models.Photo, len(pet.Photos))
+	for i, photo := range pet.Photos {
+		photo.URL = handler.blobs.URL(photo.Key)
+		photo.ThumbnailURL = handler.blobs.URL(photo.ThumbnailKey)
+		photos[i] = photo
+	}
+	pet.Photos = photos
+	return pet
+}
+
+// deletePhotoFiles удаляет файлы фотографии из хранилища. Ошибки игнорируются:
+// оставшийся в хранилище файл не влияет на работу API
+func (handler *PetHandler) deletePhotoFiles(ctx context.Context, photo models.Photo) {
+	handler.blobs.Delete(ctx, photo.Key)
+	handler.blobs.Delete(ctx, photo.ThumbnailKey)
+}
+
+// UploadPhoto загружает фотографию домашнего животного
+// @Summary Загрузка фотографии
+// @Description Загружает фотографию домашнего животного (JPEG, PNG или GIF, по умолчанию до 10 МБ и до 10000 пикселей по каждой стороне) и создаёт её миниатюру. Первая загруженная фотография становится обложкой
+// @Tags Фотографии
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID домашнего животного"
+// @Param photo formData file true "Файл изображения"
+// @Success 201 {object} models.Photo
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 413 {object} apierror.Problem "Файл или размеры изображения превышают допустимые"
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/pets/{id}/photos [post]
+func (handler *PetHandler) UploadPhoto(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid pet ID"))
+		return
+	}
+
+	pet, ok := handler.getManagedPet(c, objectID)
+	if !ok {
+		return
+	}
+
+	// Без ограничения gin прочитал бы всё тело запроса в память или во временный файл до проверки размера
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, handler.config.MaxPhotoSize+multipartOverhead)
+	fileHeader, err := c.FormFile("photo")
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		c.Error(apierror.New(http.StatusRequestEntityTooLarge, "Photo is too large"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Validation("Photo file required"))
+		return
+	}
+	if fileHeader.Size > handler.config.MaxPhotoSize {
+		c.Error(apierror.New(http.StatusRequestEntityTooLarge, "Photo is too large"))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Error(apierror.Validation("Failed to read photo"))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, handler.config.MaxPhotoSize+1))
+	if err != nil {
+		c.Error(apierror.Validation("Failed to read photo"))
+		return
+	}
+	if int64(len(data)) > handler.config.MaxPhotoSize {
+		c.Error(apierror.New(http.StatusRequestEntityTooLarge, "Photo is too large"))
+		return
+	}
+
+	// Тип определяется по содержимому файла, а не по заголовкам запроса
+	contentType := http.DetectContentType(data)
+	extension, ok := photoExtensions[contentType]
+	if !ok {
+		c.Error(apierror.Validation("Unsupported image type " + contentType))
+		return
+	}
+
+	// Размеры читаются из заголовка изображения до декодирования, которое выделяет память под все пиксели
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid image"))
+		return
+	}
+	if !handler.allowedPhotoDimensions(imageConfig.Width, imageConfig.Height) {
+		c.Error(apierror.New(http.StatusRequestEntityTooLarge, "Photo dimensions are too large"))
+		return
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid image"))
+		return
+	}
+
+	var thumbnail bytes.Buffer
+	if err := media.Thumbnail(img, handler.config.ThumbnailSize, &thumbnail); err != nil {
+		c.Error(apierror.Internal("Failed to create thumbnail", err))
+		return
+	}
+
+	photo := models.Photo{
+		ID:          primitive.NewObjectID(),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Cover:       len(pet.Photos) == 0,
+	}
+	prefix := "pets/" + objectID.Hex() + "/" + photo.ID.Hex()
+	photo.Key = prefix + extension
+	photo.ThumbnailKey = prefix + "_thumb.jpg"
+
+	if err := handler.blobs.Save(c.Request.Context(), photo.Key, bytes.NewReader(data)); err != nil {
+		c.Error(apierror.Internal("Failed to save photo", err))
+		return
+	}
+	if err := handler.blobs.Save(c.Request.Context(), photo.ThumbnailKey, &thumbnail); err != nil {
+		handler.deletePhotoFiles(c.Request.Context(), photo)
+		c.Error(apierror.Internal("Failed to save photo", err))
+		return
+	}
+
+	if err := handler.pets.AddPetPhoto(c.Request.Context(), objectID, &photo); err != nil {
+		handler.deletePhotoFiles(c.Request.Context(), photo)
+		c.Error(apierror.Internal("Failed to save photo", err))
+		return
+	}
+
+	handler.audit.Record(c, "pet.photo_upload", "pet", objectID.Hex(),
+		gin.H{"photos": pet.Photos}, gin.H{"photos": append(append([]models.Photo{}, pet.Photos...), photo)})
+
+	photo.URL = handler.blobs.URL(photo.Key)
+	photo.ThumbnailURL = handler.blobs.URL(photo.ThumbnailKey)
+	c.JSON(http.StatusCreated, photo)
+}
+
+// allowedPhotoDimensions проверяет ширину и высоту фотографии по ограничениям конфигурации
+func (handler *PetHandler) allowedPhotoDimensions(width, height int) bool {
+	if width > handler.config.MaxPhotoDimension || height > handler.config.MaxPhotoDimension {
+		return false
+	}
+	return int64(width)*int64(height) <= int64(handler.config.MaxPhotoPixels)
+}
+
+// DeletePhoto удаляет фотографию домашнего животного
+// @Summary Удаление фотографии
+// @Description Удаляет фотографию и её миниатюру. Если удалена обложка, обложкой становится первая оставшаяся фотография
+// @Tags Фотографии
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID домашнего животного"
+// @Param photoId path string true "ID фотографии"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem "Фотографии изменены параллельным запросом"
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/pets/{id}/photos/{photoId} [delete]
+func (handler *PetHandler) DeletePhoto(c *gin.Context) {
+	pet, photoID, ok := handler.getManagedPhoto(c)
+	if !ok {
+		return
+	}
+
+	var removed models.Photo
+	photos := []models.Photo{}
+	for _, photo := range pet.Photos {
+		if photo.ID == photoID {
+			removed = photo
+			continue
+		}
+		photos = append(photos, photo)
+	}
+	if removed.Cover && len(photos) > 0 {
+		photos[0].Cover = true
+	}
+
+	if !handler.setPetPhotos(c, pet, photos) {
+		return
+	}
+	handler.audit.Record(c, "pet.photo_delete", "pet", pet.ID.Hex(), gin.H{"photos": pet.Photos}, gin.H{"photos": photos})
+	handler.deletePhotoFiles(c.Request.Context(), removed)
+
+	c.JSON(http.StatusOK, gin.H{"status": "photo deleted"})
+}
+
+// SetCoverPhoto делает фотографию обложкой
+// @Summary Выбор обложки
+// @Description Делает фотографию обложкой карточки домашнего животного
+// @Tags Фотографии
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID домашнего животного"
+// @Param photoId path string true "ID фотографии"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem "Фотографии изменены параллельным запросом"
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/pets/{id}/photos/{photoId}/cover [put]
+func (handler *PetHandler) SetCoverPhoto(c *gin.Context) {
+	pet, photoID, ok := handler.getManagedPhoto(c)
+	if !ok {
+		return
+	}
+
+	photos := make([]models.Photo, len(pet.Photos))
+	for i, photo := range pet.Photos {
+		photo.Cover = photo.ID == photoID
+		photos[i] = photo
+	}
+
+	if !handler.setPetPhotos(c, pet, photos) {
+		return
+	}
+	handler.audit.Record(c, "pet.photo_cover", "pet", pet.ID.Hex(), gin.H{"photos": pet.Photos}, gin.H{"photos": photos})
+
+	c.JSON(http.StatusOK, gin.H{"status": "cover photo updated"})
+}
+
+// ReorderPhotos меняет порядок фотографий
+// @Summary Сортировка фотографий
+// @Description Задаёт порядок фотографий домашнего животного. Список должен содержать ID всех фотографий животного
+// @Tags Фотографии
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID домашнего животного"
+// @Param order body object true "photo_ids в новом порядке"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem "Фотографии изменены параллельным запросом"
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/pets/{id}/photos/order [put]
+func (handler *PetHandler) ReorderPhotos(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid pet ID"))
+		return
+	}
+
+	var input struct {
+		PhotoIDs []string `json:"photo_ids" binding:"required,dive,objectid"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	pet, ok := handler.getManagedPet(c, objectID)
+	if !ok {
+		return
+	}
+
+	byID := map[string]models.Photo{}
+	for _, photo := range pet.Photos {
+		byID[photo.ID.Hex()] = photo
+	}
+	if len(input.PhotoIDs) != len(byID) {
+		c.Error(apierror.Validation("Order must list every photo exactly once"))
+		return
+	}
+
+	photos := make([]models.Photo, 0, len(input.PhotoIDs))
+	for _, id := range input.PhotoIDs {
+		photo, ok := byID[id]
+		if !ok {
+			c.Error(apierror.Validation("Order must list every photo exactly once"))
+			return
+		}
+		delete(byID, id)
+		photos = append(photos, photo)
+	}
+
+	if !handler.setPetPhotos(c, pet, photos) {
+		return
+	}
+	handler.audit.Record(c, "pet.photo_reorder", "pet", pet.ID.Hex(), gin.H{"photos": pet.Photos}, gin.H{"photos": photos})
+
+	c.JSON(http.StatusOK, gin.H{"status": "photos reordered"})
+}
+
+// setPetPhotos - вспомогательная функция, заменяющая список фотографий животного, если его не изменил параллельный запрос.
+// Возвращает false, если ошибка уже передана в gin через c.Error
+func (handler *PetHandler) setPetPhotos(c *gin.Context, pet *models.Pet, photos []models.Photo) bool {
+	err := handler.pets.SetPetPhotos(c.Request.Context(), pet.ID, pet.Photos, photos)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Pet not found"))
+		return false
+	} else if err == databases.ErrConflict {
+		c.Error(apierror.Conflict("Photos were changed by another request, try again"))
+		return false
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to update photos", err))
+		return false
+	}
+
+	return true
+}
+
+// getManagedPhoto - вспомогательная функция, загружающая животное и проверяющая наличие у него фотографии photoId.
+// Возвращает false, если ошибка уже передана в gin через c.Error
+func (handler *PetHandler) getManagedPhoto(c *gin.Context) (*models.Pet, primitive.ObjectID, bool) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid pet ID"))
+		return nil, primitive.NilObjectID, false
+	}
+
+	photoID, err := primitive.ObjectIDFromHex(c.Param("photoId"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid photo ID"))
+		return nil, primitive.NilObjectID, false
+	}
+
+	pet, ok := handler.getManagedPet(c, objectID)
+	if !ok {
+		return nil, primitive.NilObjectID, false
+	}
+
+	for _, photo := range pet.Photos {
+		if photo.ID == photoID {
+			return pet, photoID, true
+		}
+	}
+
+	c.Error(apierror.NotFound("Photo not found"))
+	return nil, primitive.NilObjectID, false
+}
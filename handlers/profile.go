@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"context"
+	"myproject/apierror"
+	"myproject/databases"
+	"myproject/logging"
+	"myproject/models"
+	"myproject/validation"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ProfileUpdate - изменения профиля текущего пользователя. Поля, отсутствующие в запросе, не изменяются,
+// пустая строка очищает поле
+type ProfileUpdate struct {
+	DisplayName *string `json:"display_name"`
+	Email       *string `json:"email"`
+	Phone       *string `json:"phone"`
+	Housing     *string `json:"housing" enums:"apartment,house,house_with_yard,other"`
+	// PetExperience - опыт содержания домашних животных в свободной форме
+	PetExperience *string `json:"pet_experience"`
+}
+
+// GetMe возвращает аккаунт и профиль текущего пользователя
+// @Summary Мой профиль
+// @Description Возвращает аккаунт и профиль текущего пользователя
+// @Tags Профиль
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.User
+// @Failure 401 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem "Аккаунт отключён"
+// @Failure 500 {object} apierror.Problem
+// @Router /me [get]
+func (handler *UserHandler) GetMe(c *gin.Context) {
+	user, ok := handler.currentUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateMe изменяет профиль текущего пользователя
+// @Summary Изменение профиля
+// @Description Изменяет переданные поля профиля и адрес почты текущего пользователя. Новый адрес считается неподтверждённым, на него отправляется письмо подтверждения, а ссылки сброса пароля, отправленные на прежний адрес, перестают действовать
+// @Tags Профиль
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param profile body ProfileUpdate true "Изменяемые поля профиля"
+// @Success 200 {object} models.User
+// @Failure 400 {object} apierror.Problem
+// @Failure 401 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem "Аккаунт отключён"
+// @Failure 500 {object} apierror.Problem
+// @Router /me [patch]
+func (handler *UserHandler) UpdateMe(c *gin.Context) {
+	var input ProfileUpdate
+	if !bindJSON(c, &input) {
+		return
+	}
+	user, ok := handler.currentUser(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	profile := user.Profile
+	updateField(&profile.DisplayName, input.DisplayName)
+	updateField(&profile.Phone, input.Phone)
+	updateField(&profile.Housing, input.Housing)
+	updateField(&profile.PetExperience, input.PetExperience)
+	email := user.Email
+	updateField(&email, input.Email)
+
+	fields := validateStruct(&profile)
+	fields = append(fields, validateStruct(&struct {
+		Email string `json:"email" binding:"omitempty,email,max=254"`
+	}{email})...)
+	// Без адреса пользователь не смог бы подтвердить его и войти снова
+	if handler.account.RequireVerifiedEmail && email == "" {
+		fields = append(fields, validation.FieldError{Field: "email", Code: "required", Message: "is required"})
+	}
+	if len(fields) > 0 {
+		c.Error(apierror.Validation("Validation failed", fields...))
+		return
+	}
+
+	if profile != user.Profile {
+		if err := handler.users.UpdateProfile(ctx, user.ID, profile); err != nil {
+			c.Error(apierror.Internal("Failed to update profile", err))
+			return
+		}
+		user.Profile = profile
+	}
+
+	if email != user.Email {
+		if err := handler.users.SetEmail(ctx, user.ID, email); err != nil {
+			c.Error(apierror.Internal("Failed to update profile", err))
+			return
+		}
+		user.Email = email
+		user.EmailVerified = false
+
+		// Ссылки, отправленные на прежний адрес, не должны позволять сменить пароль
+		err := handler.auth.RevokeAccountTokens(ctx, user.ID, models.AccountTokenVerifyEmail, models.AccountTokenResetPassword)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to revoke account tokens after email change", "error", err)
+		}
+		if email != "" {
+			handler.sendVerification(ctx, user)
+		}
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ChangePassword изменяет пароль текущего пользователя
+// @Summary Смена пароля
+// @Description Изменяет пароль текущего пользователя после проверки текущего пароля. Все сессии пользователя завершаются, в ответе возвращаются токены новой сессии. Неверный текущий пароль учитывается как неудачная попытка входа
+// @Tags Профиль
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param password body object true "current_password и new_password"
+// @Success 200 {object} middlewares.TokenPair
+// @Failure 400 {object} apierror.Problem "Текущий пароль неверен или новый пароль не подходит"
+// @Failure 401 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem "Аккаунт отключён"
+// @Failure 429 {object} apierror.Problem "Слишком много неверных паролей, см. заголовок Retry-After"
+// @Failure 500 {object} apierror.Problem
+// @Router /me/password [put]
+func (handler *UserHandler) ChangePassword(c *gin.Context) {
+	var input struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+	user, ok := handler.currentUser(c)
+	if !ok {
+		return
+	}
+	if !handler.checkCurrentPassword(c, user, input.CurrentPassword, "current_password") {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if fieldError, ok := validation.CheckPassword(user.Username, input.NewPassword); !ok {
+		fieldError.Field = "new_password"
+		c.Error(apierror.Validation("Validation failed", fieldError))
+		return
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), handler.password.BcryptCost)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to change password", err))
+		return
+	}
+	if err := handler.users.SetPassword(ctx, user.ID, string(hash)); err != nil {
+		c.Error(apierror.Internal("Failed to change password", err))
+		return
+	}
+
+	// Старым паролем могли воспользоваться, поэтому остальные сессии и ссылки сброса пароля отзываются
+	if err := handler.auth.RevokeUserSessions(ctx, user.ID); err != nil {
+		logging.FromContext(ctx).Error("failed to revoke sessions after password change", "error", err)
+	}
+	if err := handler.auth.RevokeAccountTokens(ctx, user.ID, models.AccountTokenResetPassword); err != nil {
+		logging.FromContext(ctx).Error("failed to revoke password reset tokens", "error", err)
+	}
+
+	tokens, err := handler.auth.IssueTokens(ctx, user, "")
+	if err != nil {
+		c.Error(apierror.Internal("Failed to generate token", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// DeleteMe удаляет аккаунт текущего пользователя
+// @Summary Удаление аккаунта
+// @Description Удаляет аккаунт текущего пользователя после проверки пароля. Имя пользователя, адрес почты и профиль стираются, избранное, сохранённые поиски и уведомления удаляются, открытые заявки отзываются. Заявки сохраняются в истории приютов без персональных данных пользователя
+// @Tags Профиль
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param password body object true "password"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem "Пароль неверен"
+// @Failure 401 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem "Аккаунт отключён"
+// @Failure 429 {object} apierror.Problem "Слишком много неверных паролей, см. заголовок Retry-After"
+// @Failure 500 {object} apierror.Problem
+// @Router /me [delete]
+func (handler *UserHandler) DeleteMe(c *gin.Context) {
+	var input struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+	user, ok := handler.currentUser(c)
+	if !ok {
+		return
+	}
+	if !handler.checkCurrentPassword(c, user, input.Password, "password") {
+		return
+	}
+
+	err := handler.users.AnonymizeUser(c.Request.Context(), user.ID, time.Now())
+	if err == databases.ErrNotFound {
+		// Аккаунт удалён параллельным запросом
+		c.Error(apierror.NotFound("User not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to delete account", err))
+		return
+	}
+
+	// Аккаунт уже обезличен, поэтому оставшиеся данные удаляются, даже если клиент разорвал соединение
+	handler.deleteUserData(context.WithoutCancel(c.Request.Context()), user)
+	handler.audit.Record(c, "user.delete", "user", user.ID.Hex(), nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"status": "account deleted"})
+}
+
+// deleteUserData удаляет данные удалённого аккаунта user, которые хранятся отдельно от него, и отзывает его сессии.
+// Аккаунт к этому моменту уже удалён, поэтому ошибки не прерывают запрос и только пишутся в лог
+func (handler *UserHandler) deleteUserData(ctx context.Context, user *models.User) {
+	logger := logging.FromContext(ctx).With("user_id", user.ID.Hex())
+
+	if err := handler.auth.RevokeUserSessions(ctx, user.ID); err != nil {
+		logger.Error("failed to revoke sessions of deleted account", "error", err)
+	}
+	err := handler.auth.RevokeAccountTokens(ctx, user.ID, models.AccountTokenVerifyEmail, models.AccountTokenResetPassword)
+	if err != nil {
+		logger.Error("failed to revoke account tokens of deleted account", "error", err)
+	}
+	// Счётчик попыток входа хранится по имени пользователя, которое больше не используется
+	if err := handler.lockout.Success(ctx, user.Username); err != nil {
+		logger.Error("failed to reset login attempts of deleted account", "error", err)
+	}
+
+	if err := handler.favorites.DeleteFavoritesForUser(ctx, user.ID); err != nil {
+		logger.Error("failed to delete favorites of deleted account", "error", err)
+	}
+	if err := handler.notifications.DeleteNotificationsForUser(ctx, user.ID); err != nil {
+		logger.Error("failed to delete notifications of deleted account", "error", err)
+	}
+	searches, err := handler.searches.FindSavedSearches(ctx, user.ID)
+	if err != nil {
+		logger.Error("failed to retrieve searches of deleted account", "error", err)
+	}
+	for _, search := range searches {
+		if err := handler.searches.DeleteSavedSearch(ctx, search.ID); err != nil {
+			logger.Error("failed to delete search of deleted account", "search_id", search.ID.Hex(), "error", err)
+		}
+	}
+
+	applications, err := handler.applications.FindApplications(ctx, databases.ApplicationFilter{UserID: user.ID})
+	if err != nil {
+		logger.Error("failed to retrieve applications of deleted account", "error", err)
+	}
+	for _, application := range applications {
+		if !models.CanTransitionApplication(application.Status, models.ApplicationWithdrawn) {
+			continue
+		}
+		err := handler.applications.UpdateApplicationStatus(ctx, application.ID, application.Status, models.ApplicationWithdrawn, "Account deleted")
+		if err != nil {
+			logger.Error("failed to withdraw application of deleted account", "application_id", application.ID.Hex(), "error", err)
+		}
+	}
+}
+
+// currentUser - вспомогательная функция, возвращающая текущего пользователя. Ошибка передаётся в gin через c.Error
+func (handler *UserHandler) currentUser(c *gin.Context) (*models.User, bool) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return nil, false
+	}
+
+	user, err := handler.users.GetUser(c.Request.Context(), userID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return nil, false
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve user", err))
+		return nil, false
+	}
+	// Сессии отключаемого аккаунта отзываются, но запрос мог начаться раньше
+	if user.Disabled {
+		c.Error(apierror.Forbidden("Account is disabled"))
+		return nil, false
+	}
+
+	return user, true
+}
+
+// checkCurrentPassword - вспомогательная функция, проверяющая пароль пользователя перед изменением аккаунта.
+// Неверный пароль учитывается как неудачная попытка входа, чтобы украденный токен доступа не позволял подбирать пароль.
+// field - поле запроса с паролем. Ошибка передаётся в gin через c.Error
+func (handler *UserHandler) checkCurrentPassword(c *gin.Context, user *models.User, password, field string) bool {
+	ctx := c.Request.Context()
+
+	locked, err := handler.lockout.Check(ctx, user.Username)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to check login attempts", err))
+		return false
+	}
+	if locked > 0 {
+		c.Error(apierror.TooManyRequests("Too many failed password attempts, try again later", locked))
+		return false
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) == nil {
+		return true
+	}
+
+	locked, err = handler.lockout.Failure(ctx, user.Username)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to record login attempt", "error", err)
+	}
+	if locked > 0 {
+		c.Error(apierror.TooManyRequests("Too many failed password attempts, try again later", locked))
+		return false
+	}
+	c.Error(apierror.Validation("Validation failed", validation.FieldError{Field: field, Code: "invalid", Message: "is incorrect"}))
+	return false
+}
+
+// updateField - вспомогательная функция, заменяющая значение поля, если оно передано в запросе
+func updateField(field *string, value *string) {
+	if value != nil {
+		*field = *value
+	}
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"myproject/apierror"
+	"myproject/audit"
+	"myproject/databases"
+	"myproject/logging"
+	"myproject/middlewares"
+	"myproject/models"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// roleNamePattern - допустимый формат имени роли
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,31}$`)
+
+type RoleHandler struct {
+	roles    databases.RoleStore
+	users    databases.UserStore
+	shelters databases.ShelterStore
+	auth     *middlewares.Auth
+	audit    *audit.Logger
+}
+
+func CreateRoleHandler(roles databases.RoleStore, users databases.UserStore, shelters databases.ShelterStore, auth *middlewares.Auth, audit *audit.Logger) *RoleHandler {
+	return &RoleHandler{roles: roles, users: users, shelters: shelters, auth: auth, audit: audit}
+}
+
+// userRoleState - вспомогательная функция, описывающая роль пользователя для журнала аудита
+func userRoleState(role string, shelterID primitive.ObjectID) gin.H {
+	state := gin.H{"role": role}
+	if !shelterID.IsZero() {
+		state["shelter_id"] = shelterID.Hex()
+	}
+	return state
+}
+
+// GetRoles возвращает список ролей
+// @Summary Список ролей
+// @Description Возвращает все роли и их права доступа
+// @Tags Роли
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Role
+// @Failure 403 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/roles [get]
+func (handler *RoleHandler) GetRoles(c *gin.Context) {
+	roles, err := handler.roles.FindRoles(c.Request.Context())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve roles", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetPermissions возвращает список существующих прав доступа
+// @Summary Список прав доступа
+// @Description Возвращает все права доступа, которые можно назначить ролям
+// @Tags Роли
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} string
+// @Failure 403 {object} apierror.Problem
+// @Router /admin/permissions [get]
+func (handler *RoleHandler) GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, models.AllPermissions)
+}
+
+// SaveRole создаёт или изменяет роль
+// @Summary Создание или изменение роли
+// @Description Создаёт роль с указанным именем или заменяет описание и права существующей роли
+// @Tags Роли
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Имя роли"
+// @Param role body models.Role true "Описание и права роли"
+// @Success 200 {object} models.Role
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/roles/{name} [put]
+func (handler *RoleHandler) SaveRole(c *gin.Context) {
+	name := c.Param("name")
+	if !roleNamePattern.MatchString(name) {
+		c.Error(apierror.Validation("Invalid role name"))
+		return
+	}
+
+	var input struct {
+		Description string   `json:"description" binding:"max=200"`
+		Permissions []string `json:"permissions" binding:"dive,permission"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	permissions := []string{}
+	for _, permission := range input.Permissions {
+		if !slices.Contains(permissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	role := models.Role{Name: name, Description: input.Description, Permissions: permissions}
+
+	var before interface{}
+	existing, err := handler.roles.GetRole(c.Request.Context(), name)
+	if err == nil {
+		role.BuiltIn = existing.BuiltIn
+		before = existing
+	} else if err != databases.ErrNotFound {
+		c.Error(apierror.Internal("Failed to retrieve role", err))
+		return
+	}
+
+	// Администратор не должен случайно лишить себя возможности управлять ролями
+	if name == models.RoleAdmin && !role.HasPermission(models.PermRolesManage) {
+		c.Error(apierror.Validation("Role admin must keep permission " + models.PermRolesManage))
+		return
+	}
+
+	if err := handler.roles.SaveRole(c.Request.Context(), &role); err != nil {
+		c.Error(apierror.Internal("Failed to save role", err))
+		return
+	}
+	handler.audit.Record(c, "role.save", "role", name, before, role)
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole удаляет роль
+// @Summary Удаление роли
+// @Description Удаляет роль. Встроенные роли удалить нельзя
+// @Tags Роли
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Имя роли"
+// @Success 200 {object} map[string]string "status"
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/roles/{name} [delete]
+func (handler *RoleHandler) DeleteRole(c *gin.Context) {
+	role, err := handler.roles.GetRole(c.Request.Context(), c.Param("name"))
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Role not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve role", err))
+		return
+	}
+
+	if role.BuiltIn {
+		c.Error(apierror.Conflict("Built-in role can not be deleted"))
+		return
+	}
+
+	if err := handler.roles.DeleteRole(c.Request.Context(), role.Name); err != nil {
+		c.Error(apierror.Internal("Failed to delete role", err))
+		return
+	}
+	handler.audit.Record(c, "role.delete", "role", role.Name, role, nil)
+
+	c.JSON(http.StatusOK, gin.H{"status": "role deleted"})
+}
+
+// AssignRole назначает роль пользователю
+// @Summary Назначение роли пользователю
+// @Description Назначает пользователю роль и, для сотрудников приютов, приют. Нельзя назначить роль, обладающую правами, которых нет у самого администратора, и изменить роль пользователя с такими правами. Приют указывается только для роли shelter_staff. Все сессии пользователя завершаются
+// @Tags Роли
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param username path string true "username пользователя"
+// @Param role body object true "role и необязательный shelter_id"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/users/{username}/role [put]
+func (handler *RoleHandler) AssignRole(c *gin.Context) {
+	var input struct {
+		Role      string `json:"role" binding:"required"`
+		ShelterID string `json:"shelter_id" binding:"omitempty,objectid"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	role, err := handler.roles.GetRole(c.Request.Context(), input.Role)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.Validation("Role not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve role", err))
+		return
+	}
+
+	// Защита от повышения привилегий: выдать можно только роль, все права которой есть у назначающего
+	actorRole, err := handler.roles.GetRole(c.Request.Context(), c.GetString("role"))
+	if err != nil {
+		c.Error(apierror.Forbidden("Access forbidden"))
+		return
+	}
+	for _, permission := range role.Permissions {
+		if !actorRole.HasPermission(permission) {
+			c.Error(apierror.Forbidden("Can not grant permission " + permission))
+			return
+		}
+	}
+
+	user, err := handler.users.GetUserByUsername(c.Request.Context(), c.Param("username"))
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("User not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve user", err))
+		return
+	}
+	if !checkManagedUser(c, handler.roles, user) {
+		return
+	}
+
+	shelterID := primitive.NilObjectID
+	if input.ShelterID != "" && role.Name != models.RoleShelterStaff {
+		c.Error(apierror.Validation("Shelter ID is allowed only for role " + models.RoleShelterStaff))
+		return
+	} else if input.ShelterID != "" {
+		shelterID, err = primitive.ObjectIDFromHex(input.ShelterID)
+		if err != nil {
+			c.Error(apierror.Validation("Invalid shelter ID"))
+			return
+		}
+
+		_, err = handler.shelters.GetShelter(c.Request.Context(), shelterID)
+		if err == databases.ErrNotFound {
+			c.Error(apierror.Validation("Shelter not found"))
+			return
+		} else if err != nil {
+			c.Error(apierror.Internal("Failed to retrieve shelter", err))
+			return
+		}
+	} else if role.Name == models.RoleShelterStaff {
+		c.Error(apierror.Validation("Shelter ID required for role " + models.RoleShelterStaff))
+		return
+	}
+
+	if err := handler.users.SetUserRole(c.Request.Context(), user.ID, role.Name, shelterID); err != nil {
+		c.Error(apierror.Internal("Failed to update user", err))
+		return
+	}
+	// Роль и приют записаны в выданные токены, поэтому прежние сессии завершаются
+	if err := handler.auth.RevokeUserSessions(c.Request.Context(), user.ID); err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to revoke sessions after role change", "user_id", user.ID.Hex(), "error", err)
+	}
+	handler.audit.Record(c, "user.role", "user", user.ID.Hex(),
+		userRoleState(user.Role, user.ShelterID), userRoleState(role.Name, shelterID))
+
+	c.JSON(http.StatusOK, gin.H{"status": "role assigned"})
+}
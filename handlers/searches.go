@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"myproject/apierror"
+	"myproject/databases"
+	"myproject/models"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SearchHandler struct {
+	searches      databases.SearchStore
+	notifications databases.NotificationStore
+}
+
+func CreateSearchHandler(searches databases.SearchStore, notifications databases.NotificationStore) *SearchHandler {
+	return &SearchHandler{searches: searches, notifications: notifications}
+}
+
+// CreateSearch сохраняет поиск текущего пользователя
+// @Summary Сохранение поиска
+// @Description Сохраняет параметры фильтрации GET /pets (например, species=dog&breed=husky&age_max=3) под указанным именем. О новых подходящих животных пользователь получает уведомления сразу (immediate) или в ежедневной сводке (daily, по умолчанию)
+// @Tags Сохранённые поиски
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param search body models.SavedSearch true "name, query и mode"
+// @Success 201 {object} models.SavedSearch
+// @Failure 400 {object} apierror.Problem
+// @Failure 401 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /searches [post]
+func (handler *SearchHandler) CreateSearch(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return
+	}
+
+	var input struct {
+		Name  string `json:"name" binding:"required,max=100"`
+		Query string `json:"query" binding:"max=2000"`
+		Mode  string `json:"mode" binding:"omitempty,search_mode"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		c.Error(apierror.Validation("Search name required"))
+		return
+	}
+	if input.Mode == "" {
+		input.Mode = models.SearchModeDaily
+	}
+
+	// Строка запроса может быть скопирована из адреса вместе с "?"
+	values, err := url.ParseQuery(strings.TrimPrefix(input.Query, "?"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid query"))
+		return
+	}
+	filter, err := databases.ParsePetFilter(values)
+	if err != nil {
+		c.Error(apierror.Validation(err.Error()))
+		return
+	}
+
+	// Сохраняется только фильтр, параметры сортировки и страницы отбрасываются
+	search := models.SavedSearch{
+		UserID:    userID,
+		Name:      input.Name,
+		Query:     filter.Values().Encode(),
+		Mode:      input.Mode,
+		CreatedAt: time.Now(),
+	}
+	if err := handler.searches.CreateSavedSearch(c.Request.Context(), &search); err != nil {
+		c.Error(apierror.Internal("Failed to save search", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+// GetSearches возвращает сохранённые поиски текущего пользователя
+// @Summary Мои сохранённые поиски
+// @Description Возвращает сохранённые поиски текущего пользователя
+// @Tags Сохранённые поиски
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.SavedSearch
+// @Failure 401 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /searches [get]
+func (handler *SearchHandler) GetSearches(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return
+	}
+
+	searches, err := handler.searches.FindSavedSearches(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve searches", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, searches)
+}
+
+// DeleteSearch удаляет сохранённый поиск текущего пользователя
+// @Summary Удаление сохранённого поиска
+// @Description Удаляет сохранённый поиск текущего пользователя, уведомления по нему больше не отправляются
+// @Tags Сохранённые поиски
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID сохранённого поиска"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 401 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /searches/{id} [delete]
+func (handler *SearchHandler) DeleteSearch(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid search ID"))
+		return
+	}
+
+	// Чужой поиск не отличается от несуществующего
+	search, err := handler.searches.GetSavedSearch(c.Request.Context(), objectID)
+	if err == databases.ErrNotFound || (err == nil && search.UserID != userID) {
+		c.Error(apierror.NotFound("Search not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve search", err))
+		return
+	}
+
+	if err := handler.searches.DeleteSavedSearch(c.Request.Context(), objectID); err != nil && err != databases.ErrNotFound {
+		c.Error(apierror.Internal("Failed to delete search", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "search deleted"})
+}
+
+// GetNotifications возвращает уведомления текущего пользователя
+// @Summary Мои уведомления
+// @Description Возвращает уведомления текущего пользователя, начиная с последних
+// @Tags Уведомления
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Notification
+// @Failure 401 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /notifications [get]
+func (handler *SearchHandler) GetNotifications(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return
+	}
+
+	notifications, err := handler.notifications.FindNotifications(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve notifications", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// ReadNotification помечает уведомление прочитанным
+// @Summary Прочтение уведомления
+// @Description Помечает уведомление текущего пользователя прочитанным
+// @Tags Уведомления
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID уведомления"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 401 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /notifications/{id}/read [post]
+func (handler *SearchHandler) ReadNotification(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid notification ID"))
+		return
+	}
+
+	err = handler.notifications.MarkNotificationRead(c.Request.Context(), userID, objectID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Notification not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to update notification", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "notification read"})
+}
@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"myproject/apierror"
+	"myproject/audit"
+	"myproject/databases"
+	"myproject/logging"
+	"myproject/middlewares"
+	"myproject/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ShelterHandler struct {
+	shelters databases.ShelterStore
+	pets     databases.PetStore
+	users    databases.UserStore
+	auth     *middlewares.Auth
+	audit    *audit.Logger
+}
+
+func CreateShelterHandler(shelters databases.ShelterStore, pets databases.PetStore, users databases.UserStore, auth *middlewares.Auth, audit *audit.Logger) *ShelterHandler {
+	return &ShelterHandler{shelters: shelters, pets: pets, users: users, auth: auth, audit: audit}
+}
+
+// GetShelters возвращает список приютов
+// @Summary Список приютов
+// @Description Возвращает список всех приютов
+// @Tags Приюты
+// @Produce json
+// @Success 200 {array} models.Shelter
+// @Failure 500 {object} apierror.Problem
+// @Router /shelters [get]
+func (handler *ShelterHandler) GetShelters(c *gin.Context) {
+	shelters, err := handler.shelters.FindShelters(c.Request.Context())
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve shelters", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, shelters)
+}
+
+// GetShelter возвращает приют по ID
+// @Summary Получение приюта
+// @Description Возвращает информацию о приюте по ID
+// @Tags Приюты
+// @Produce json
+// @Param id path string true "ID приюта"
+// @Success 200 {object} models.Shelter
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /shelters/{id} [get]
+func (handler *ShelterHandler) GetShelter(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid shelter ID"))
+		return
+	}
+
+	shelter, err := handler.shelters.GetShelter(c.Request.Context(), objectID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Shelter not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve shelter", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, shelter)
+}
+
+// CreateShelter добавляет новый приют
+// @Summary Создание приюта
+// @Description Создаёт новый приют
+// @Tags Приюты
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param shelter body models.Shelter true "Информация о приюте"
+// @Success 201 {object} models.Shelter
+// @Failure 400 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/shelters [post]
+func (handler *ShelterHandler) CreateShelter(c *gin.Context) {
+	var shelter models.Shelter
+	if !bindJSON(c, &shelter) {
+		return
+	}
+
+	if shelter.Name == "" {
+		c.Error(apierror.Validation("Shelter name required"))
+		return
+	}
+
+	if err := handler.shelters.CreateShelter(c.Request.Context(), &shelter); err != nil {
+		c.Error(apierror.Internal("Could not create shelter", err))
+		return
+	}
+	handler.audit.Record(c, "shelter.create", "shelter", shelter.ID.Hex(), nil, shelter)
+
+	c.JSON(http.StatusCreated, shelter)
+}
+
+// UpdateShelter обновляет данные приюта
+// @Summary Обновление приюта
+// @Description Обновляет данные приюта по ID
+// @Tags Приюты
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID приюта"
+// @Param shelter body models.Shelter true "Новые данные приюта"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/shelters/{id} [put]
+func (handler *ShelterHandler) UpdateShelter(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid shelter ID"))
+		return
+	}
+
+	var shelter models.Shelter
+	if !bindJSON(c, &shelter) {
+		return
+	}
+
+	current, ok := handler.getShelter(c, objectID)
+	if !ok {
+		return
+	}
+
+	err = handler.shelters.UpdateShelter(c.Request.Context(), objectID, &shelter)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Shelter not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to update shelter", err))
+		return
+	}
+	shelter.ID = objectID
+	handler.audit.Record(c, "shelter.update", "shelter", objectID.Hex(), current, shelter)
+
+	c.JSON(http.StatusOK, gin.H{"status": "shelter updated"})
+}
+
+// DeleteShelter удаляет приют
+// @Summary Удаление приюта
+// @Description Удаляет приют по ID. Приют, в котором есть животные, удалить нельзя
+// @Tags Приюты
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID приюта"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/shelters/{id} [delete]
+func (handler *ShelterHandler) DeleteShelter(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid shelter ID"))
+		return
+	}
+
+	shelter, ok := handler.getShelter(c, objectID)
+	if !ok {
+		return
+	}
+
+	pets, err := handler.pets.FindPets(c.Request.Context(), databases.PetFilter{ShelterID: objectID}, databases.PageRequest{Limit: 1})
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve pets", err))
+		return
+	}
+	if pets.Total > 0 {
+		c.Error(apierror.Conflict("Shelter still has pets"))
+		return
+	}
+
+	err = handler.shelters.DeleteShelter(c.Request.Context(), objectID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Shelter not found"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to delete shelter", err))
+		return
+	}
+	handler.audit.Record(c, "shelter.delete", "shelter", objectID.Hex(), shelter, nil)
+
+	c.JSON(http.StatusOK, gin.H{"status": "shelter deleted"})
+}
+
+// AddStaff назначает пользователя сотрудником приюта
+// @Summary Назначение сотрудника приюта
+// @Description Выдаёт роль shelter_staff в указанном приюте пользователю с ролью user. Пользователей с другими ролями, в том числе сотрудников других приютов, нужно сначала перевести в роль user. Все сессии пользователя завершаются
+// @Tags Приюты
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID приюта"
+// @Param staff body object true "username пользователя"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem "Пользователь уже имеет другую роль или его аккаунт отключён"
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/shelters/{id}/staff [post]
+func (handler *ShelterHandler) AddStaff(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid shelter ID"))
+		return
+	}
+
+	var input struct {
+		Username string `json:"username" binding:"required"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	if _, ok := handler.getShelter(c, objectID); !ok {
+		return
+	}
+
+	user, ok := handler.getUser(c, input.Username)
+	if !ok {
+		return
+	}
+	// Назначение не должно незаметно понижать администратора или переводить сотрудника из другого приюта
+	if user.DeletedAt != nil {
+		c.Error(apierror.NotFound("User not found"))
+		return
+	}
+	if user.Disabled {
+		c.Error(apierror.Conflict("Account is disabled"))
+		return
+	}
+	if user.Role != models.RoleUser {
+		c.Error(apierror.Conflict("User already has role " + user.Role))
+		return
+	}
+
+	if !handler.setUserRole(c, user, models.RoleShelterStaff, objectID) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "staff added"})
+}
+
+// RemoveStaff снимает с пользователя роль сотрудника приюта
+// @Summary Удаление сотрудника приюта
+// @Description Возвращает сотруднику приюта роль обычного пользователя и завершает все его сессии
+// @Tags Приюты
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID приюта"
+// @Param username path string true "username сотрудника"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/shelters/{id}/staff/{username} [delete]
+func (handler *ShelterHandler) RemoveStaff(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid shelter ID"))
+		return
+	}
+
+	user, ok := handler.getUser(c, c.Param("username"))
+	if !ok {
+		return
+	}
+	if user.Role != models.RoleShelterStaff || user.ShelterID != objectID {
+		c.Error(apierror.NotFound("Staff member not found"))
+		return
+	}
+
+	if !handler.setUserRole(c, user, models.RoleUser, primitive.NilObjectID) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "staff removed"})
+}
+
+// setUserRole - вспомогательная функция, назначающая пользователю роль и приют. Роль и приют записаны
+// в выданные токены, поэтому прежние сессии пользователя завершаются.
+// Возвращает false, если ошибка уже передана в gin через c.Error
+func (handler *ShelterHandler) setUserRole(c *gin.Context, user *models.User, role string, shelterID primitive.ObjectID) bool {
+	if err := handler.users.SetUserRole(c.Request.Context(), user.ID, role, shelterID); err != nil {
+		c.Error(apierror.Internal("Failed to update user", err))
+		return false
+	}
+	if err := handler.auth.RevokeUserSessions(c.Request.Context(), user.ID); err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to revoke sessions after role change", "user_id", user.ID.Hex(), "error", err)
+	}
+	handler.audit.Record(c, "user.role", "user", user.ID.Hex(),
+		userRoleState(user.Role, user.ShelterID), userRoleState(role, shelterID))
+
+	return true
+}
+
+// getUser - вспомогательная функция для поиска пользователя по имени.
+// Возвращает false, если ошибка уже передана в gin через c.Error
+func (handler *ShelterHandler) getUser(c *gin.Context, username string) (*models.User, bool) {
+	user, err := handler.users.GetUserByUsername(c.Request.Context(), username)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("User not found"))
+		return nil, false
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve user", err))
+		return nil, false
+	}
+
+	return user, true
+}
+
+// getShelter - вспомогательная функция для поиска приюта по ID.
+// Возвращает false, если ошибка уже передана в gin через c.Error
+func (handler *ShelterHandler) getShelter(c *gin.Context, id primitive.ObjectID) (*models.Shelter, bool) {
+	shelter, err := handler.shelters.GetShelter(c.Request.Context(), id)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Shelter not found"))
+		return nil, false
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve shelter", err))
+		return nil, false
+	}
+
+	return shelter, true
+}
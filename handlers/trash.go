@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"myproject/apierror"
+	"myproject/databases"
+	"myproject/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetTrash возвращает домашних животных из корзины
+// @Summary Корзина домашних животных
+// @Description Возвращает удалённых домашних животных, начиная с последних удалённых. Сотрудник приюта видит только животных своего приюта
+// @Tags Домашние животные
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Pet
+// @Failure 403 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/pets/trash [get]
+func (handler *PetHandler) GetTrash(c *gin.Context) {
+	shelterID := primitive.NilObjectID
+	if c.GetString("shelterID") != "" {
+		var err error
+		shelterID, err = primitive.ObjectIDFromHex(c.GetString("shelterID"))
+		if err != nil {
+			c.Error(apierror.Forbidden("Access forbidden"))
+			return
+		}
+	}
+
+	pets, err := handler.pets.FindDeletedPets(c.Request.Context(), shelterID)
+	if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve pets", err))
+		return
+	}
+
+	items := make([]models.Pet, len(pets))
+	for i, pet := range pets {
+		items[i] = handler.withPhotoURLs(pet)
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// RestorePet возвращает домашнее животное из корзины
+// @Summary Восстановление домашнего животного
+// @Description Возвращает домашнее животное из корзины вместе с фотографиями и статусом. Сотрудник приюта может восстанавливать только животных своего приюта
+// @Tags Домашние животные
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID домашнего животного"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/pets/{id}/restore [post]
+func (handler *PetHandler) RestorePet(c *gin.Context) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.Error(apierror.Validation("Invalid pet ID"))
+		return
+	}
+
+	pet, err := handler.pets.GetDeletedPet(c.Request.Context(), objectID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Pet not found in trash"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve pet", err))
+		return
+	}
+	if !canManagePet(c, pet) {
+		c.Error(apierror.Forbidden("Access forbidden"))
+		return
+	}
+
+	// Приют мог быть удалён, пока животное было в корзине
+	_, err = handler.shelters.GetShelter(c.Request.Context(), pet.ShelterID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.Conflict("Shelter of the pet no longer exists"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve shelter", err))
+		return
+	}
+
+	err = handler.pets.RestorePet(c.Request.Context(), objectID)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("Pet not found in trash"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to restore pet", err))
+		return
+	}
+
+	restored := *pet
+	restored.DeletedAt = nil
+	restored.DeletedBy = nil
+	handler.audit.Record(c, "pet.restore", "pet", objectID.Hex(), pet, restored)
+	handler.matcher.PetChanged(objectID)
+
+	c.JSON(http.StatusOK, gin.H{"status": "pet restored"})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"myproject/apierror"
+	"myproject/databases"
+	"myproject/logging"
+	"myproject/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserListResponse - страница списка пользователей
+type UserListResponse struct {
+	Items []models.User `json:"items"`
+	Total int64         `json:"total"`
+	Limit int           `json:"limit"`
+	// Page - номер страницы, не заполняется при выдаче по курсору
+	Page       int    `json:"page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// GetUsers возвращает список пользователей для администратора
+// @Summary Список пользователей
+// @Description Возвращает пользователей с поиском по имени, адресу почты и отображаемому имени и фильтрацией по роли и отключению. Удалённые аккаунты не возвращаются
+// @Tags Пользователи
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Подстрока имени пользователя, адреса почты или отображаемого имени"
+// @Param role query string false "Роль пользователя"
+// @Param disabled query bool false "true - только отключённые аккаунты, false - только действующие"
+// @Param page query int false "Номер страницы (начиная с 1)"
+// @Param limit query int false "Размер страницы (от 1 до 100, по умолчанию 20)"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Param sort query string false "Поле сортировки: username или created_at (по умолчанию); префикс - для сортировки по убыванию"
+// @Success 200 {object} UserListResponse
+// @Header 200 {string} Link "Ссылки на следующую, первую, последнюю и предыдущую страницы"
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/users [get]
+func (handler *UserHandler) GetUsers(c *gin.Context) {
+	filter := databases.UserFilter{
+		Query: strings.TrimSpace(c.Query("q")),
+		Role:  c.Query("role"),
+	}
+	if value := c.Query("disabled"); value != "" {
+		disabled, err := strconv.ParseBool(value)
+		if err != nil {
+			c.Error(apierror.Validation("Invalid disabled"))
+			return
+		}
+		filter.Disabled = &disabled
+	}
+
+	pageRequest, page, err := parsePageRequest(c, databases.IsUserSort)
+	if err != nil {
+		c.Error(apierror.Validation(err.Error()))
+		return
+	}
+
+	result, err := handler.users.FindUsers(c.Request.Context(), filter, pageRequest)
+	if err == databases.ErrInvalidCursor {
+		c.Error(apierror.Validation("Invalid cursor"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve users", err))
+		return
+	}
+
+	response := UserListResponse{
+		Items:      result.Users,
+		Total:      result.Total,
+		Limit:      pageRequest.Limit,
+		NextCursor: result.NextCursor,
+	}
+	if pageRequest.Cursor == "" {
+		response.Page = page
+	}
+
+	setLinkHeader(c, pageRequest, page, result.Total, result.NextCursor)
+	c.JSON(http.StatusOK, response)
+}
+
+// DisableUser отключает аккаунт пользователя
+// @Summary Отключение аккаунта
+// @Description Отключает аккаунт пользователя и завершает все его сессии: пользователь не может войти, пока аккаунт не будет включён снова. Нельзя отключить свой аккаунт и аккаунт пользователя с правами, которых нет у самого администратора
+// @Tags Пользователи
+// @Produce json
+// @Security BearerAuth
+// @Param username path string true "username пользователя"
+// @Success 200 {object} map[string]string "status"
+// @Failure 400 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/users/{username}/disable [post]
+func (handler *UserHandler) DisableUser(c *gin.Context) {
+	user, ok := handler.getManagedUser(c)
+	if !ok {
+		return
+	}
+	if user.ID.Hex() == c.GetString("userID") {
+		c.Error(apierror.Validation("Can not disable your own account"))
+		return
+	}
+
+	if !handler.setUserDisabled(c, user, true) {
+		return
+	}
+	if err := handler.auth.RevokeUserSessions(c.Request.Context(), user.ID); err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to revoke sessions of disabled account", "user_id", user.ID.Hex(), "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "user disabled"})
+}
+
+// EnableUser снова включает отключённый аккаунт пользователя
+// @Summary Включение аккаунта
+// @Description Снова разрешает вход в отключённый аккаунт пользователя. Удалённый пользователем аккаунт включить нельзя
+// @Tags Пользователи
+// @Produce json
+// @Security BearerAuth
+// @Param username path string true "username пользователя"
+// @Success 200 {object} map[string]string "status"
+// @Failure 403 {object} apierror.Problem
+// @Failure 404 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /admin/users/{username}/enable [post]
+func (handler *UserHandler) EnableUser(c *gin.Context) {
+	user, ok := handler.getManagedUser(c)
+	if !ok {
+		return
+	}
+	if !handler.setUserDisabled(c, user, false) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "user enabled"})
+}
+
+// getManagedUser - вспомогательная функция, возвращающая пользователя из пути запроса, если администратор
+// может им управлять. Ошибка передаётся в gin через c.Error
+func (handler *UserHandler) getManagedUser(c *gin.Context) (*models.User, bool) {
+	user, err := handler.users.GetUserByUsername(c.Request.Context(), c.Param("username"))
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("User not found"))
+		return nil, false
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve user", err))
+		return nil, false
+	}
+	if !checkManagedUser(c, handler.roles, user) {
+		return nil, false
+	}
+
+	return user, true
+}
+
+// checkManagedUser - вспомогательная функция, проверяющая, может ли текущий пользователь изменять аккаунт user.
+// Удалённый аккаунт считается несуществующим. Защита от повышения привилегий: управлять можно только пользователем,
+// все права роли которого есть у текущего пользователя, иначе можно было бы, например, понизить администратора.
+// Ошибка передаётся в gin через c.Error
+func checkManagedUser(c *gin.Context, roles databases.RoleStore, user *models.User) bool {
+	if user.DeletedAt != nil {
+		c.Error(apierror.NotFound("User not found"))
+		return false
+	}
+
+	actorRole, err := roles.GetRole(c.Request.Context(), c.GetString("role"))
+	if err != nil {
+		c.Error(apierror.Forbidden("Access forbidden"))
+		return false
+	}
+	role, err := roles.GetRole(c.Request.Context(), user.Role)
+	if err == databases.ErrNotFound {
+		// Роль пользователя удалена, прав у него нет
+		return true
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to retrieve role", err))
+		return false
+	}
+	for _, permission := range role.Permissions {
+		if !actorRole.HasPermission(permission) {
+			c.Error(apierror.Forbidden("Can not manage user with permission " + permission))
+			return false
+		}
+	}
+
+	return true
+}
+
+// setUserDisabled - вспомогательная функция, отключающая или включающая аккаунт и записывающая это в журнал аудита.
+// Ошибка передаётся в gin через c.Error
+func (handler *UserHandler) setUserDisabled(c *gin.Context, user *models.User, disabled bool) bool {
+	err := handler.users.SetUserDisabled(c.Request.Context(), user.ID, disabled)
+	if err == databases.ErrNotFound {
+		c.Error(apierror.NotFound("User not found"))
+		return false
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to update user", err))
+		return false
+	}
+
+	action := "user.enable"
+	if disabled {
+		action = "user.disable"
+	}
+	handler.audit.Record(c, action, "user", user.ID.Hex(), gin.H{"disabled": user.Disabled}, gin.H{"disabled": disabled})
+	return true
+}
@@ -2,102 +2,278 @@ package handlers
 
 import (
 	"context"
+	"myproject/apierror"
+	"myproject/audit"
+	"myproject/config"
 	"myproject/databases"
+	"myproject/logging"
+	"myproject/metrics"
 	"myproject/middlewares"
 	"myproject/models"
+	"myproject/notifications"
+	"myproject/ratelimit"
+	"myproject/validation"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserHandler struct {
-	database *databases.MongoDB
+	users databases.UserStore
+	// roles нужны, чтобы администратор не мог отключить пользователя с правами, которых нет у него самого
+	roles databases.RoleStore
+	// applications, favorites, searches и notifications нужны, чтобы закрыть заявки и удалить данные
+	// пользователя вместе с аккаунтом
+	applications  databases.ApplicationStore
+	favorites     databases.FavoriteStore
+	searches      databases.SearchStore
+	notifications databases.NotificationStore
+	auth          *middlewares.Auth
+	lockout       *ratelimit.Lockout
+	mailer        notifications.Mailer
+	audit         *audit.Logger
+	password      config.PasswordConfig
+	account       config.AccountConfig
+	// dummyHash сравнивается с паролем, если пользователь не найден, чтобы время ответа
+	// не раскрывало, существует ли пользователь
+	dummyHash []byte
 }
 
-func CreateUserHandler(database *databases.MongoDB) *UserHandler {
-	return &UserHandler{database: database}
+func CreateUserHandler(users databases.UserStore, roles databases.RoleStore, applications databases.ApplicationStore, favorites databases.FavoriteStore,
+	searches databases.SearchStore, notifications databases.NotificationStore, auth *middlewares.Auth, lockout *ratelimit.Lockout,
+	mailer notifications.Mailer, audit *audit.Logger, password config.PasswordConfig, account config.AccountConfig) *UserHandler {
+	// Сложность проверена при загрузке конфигурации, поэтому хеширование не завершается ошибкой
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), password.BcryptCost)
+	return &UserHandler{users: users, roles: roles, applications: applications, favorites: favorites, searches: searches, notifications: notifications,
+		auth: auth, lockout: lockout, mailer: mailer, audit: audit, password: password, account: account, dummyHash: dummyHash}
 }
 
 // GetUserByUsername - вспомогательная функция для поиска пользователя по имени
-func (handler *UserHandler) GetUserByUsername(username string) (*models.User, error) {
-	collection := handler.database.Collection("users")
-
-	var user models.User
-	err := collection.FindOne(context.TODO(), bson.M{"username": username}).Decode(&user)
-	if err != nil {
-		return nil, err
-	}
-
-	return &user, nil
+func (handler *UserHandler) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	return handler.users.GetUserByUsername(ctx, username)
 }
 
 // Login Выполняет вход в аккаунт пользоваетля по username и password
 // @Summary Выполняет вход в аккаунт пользоваетля
-// @Description Выполняет вход в аккаунт пользоваетля по username и password
+// @Description Выполняет вход в аккаунт пользоваетля по username и password. После нескольких неудачных попыток подряд вход под этим именем временно блокируется
 // @Tags Пользователи
 // @Accept json
 // @Produce json
-// @Param credentials body models.User true "username и password пользователя"
-// @Success 200 {object} map[string]string "token"
-// @Failure 401 {object} map[string]string "error"
+// @Param credentials body object true "username и password пользователя"
+// @Success 200 {object} middlewares.TokenPair
+// @Failure 400 {object} apierror.Problem
+// @Failure 401 {object} apierror.Problem
+// @Failure 403 {object} apierror.Problem "Аккаунт отключён или адрес почты не подтверждён (если подтверждение обязательно)"
+// @Failure 429 {object} apierror.Problem "Слишком много запросов или вход заблокирован, см. заголовок Retry-After"
+// @Failure 500 {object} apierror.Problem
 // @Router /login [post]
 func (handler *UserHandler) Login(c *gin.Context) {
 	var input struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
 	}
-	if err := c.BindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+	if !bindJSON(c, &input) {
 		return
 	}
 
-	user, err := handler.GetUserByUsername(input.Username)
+	// Заблокированное имя отклоняется до проверки пароля, иначе подбор можно было бы продолжать
+	locked, err := handler.lockout.Check(c.Request.Context(), input.Username)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		metrics.RecordLogin(metrics.LoginError)
+		c.Error(apierror.Internal("Failed to check login attempts", err))
+		return
+	}
+	if locked > 0 {
+		metrics.RecordLogin(metrics.LoginLocked)
+		c.Error(apierror.TooManyRequests("Too many failed login attempts, try again later", locked))
+		return
+	}
+
+	user, err := handler.GetUserByUsername(c.Request.Context(), input.Username)
+	if err == databases.ErrNotFound {
+		// Пароль проверяется и для несуществующего пользователя, чтобы ответ занимал столько же времени
+		bcrypt.CompareHashAndPassword(handler.dummyHash, []byte(input.Password))
+		handler.loginFailed(c, input.Username)
+		return
+	} else if err != nil {
+		metrics.RecordLogin(metrics.LoginError)
+		c.Error(apierror.Internal("Failed to retrieve user", err))
 		return
 	}
 
 	// Проверка пароля
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		handler.loginFailed(c, input.Username)
+		return
+	}
+	// Проверяется только после верного пароля, чтобы ответ не раскрывал, есть ли такой пользователь
+	if user.Disabled {
+		metrics.RecordLogin(metrics.LoginFailure)
+		c.Error(apierror.Forbidden("Account is disabled"))
+		return
+	}
+	if handler.account.RequireVerifiedEmail && !user.EmailVerified {
+		metrics.RecordLogin(metrics.LoginFailure)
+		c.Error(apierror.Forbidden("Email address is not verified"))
+		return
+	}
+
+	// Генерация JWT и токена обновления для новой сессии
+	tokens, err := handler.auth.IssueTokens(c.Request.Context(), user, "")
+	if err != nil {
+		metrics.RecordLogin(metrics.LoginError)
+		c.Error(apierror.Internal("Failed to generate token", err))
+		return
+	}
+
+	if err := handler.lockout.Success(c.Request.Context(), input.Username); err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to reset login attempts", "error", err)
+	}
+
+	metrics.RecordLogin(metrics.LoginSuccess)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// loginFailed - вспомогательная функция, учитывающая неудачную попытку входа под именем username.
+// Ошибка передаётся в gin через c.Error
+func (handler *UserHandler) loginFailed(c *gin.Context, username string) {
+	metrics.RecordLogin(metrics.LoginFailure)
+
+	locked, err := handler.lockout.Failure(c.Request.Context(), username)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("failed to record login attempt", "error", err)
+	}
+	if locked > 0 {
+		logging.FromContext(c.Request.Context()).Warn("login locked after failed attempts", "username", username, "locked_for", locked.String())
+		c.Error(apierror.TooManyRequests("Too many failed login attempts, try again later", locked))
+		return
+	}
+	c.Error(apierror.Unauthorized("Invalid username or password"))
+}
+
+// RefreshToken выдаёт новую пару токенов по токену обновления
+// @Summary Обновление токенов
+// @Description Обменивает токен обновления на новый токен доступа и новый токен обновления. Каждый токен обновления можно использовать только один раз, повторное использование отзывает все токены сессии
+// @Tags Пользователи
+// @Accept json
+// @Produce json
+// @Param token body object true "refresh_token"
+// @Success 200 {object} middlewares.TokenPair
+// @Failure 400 {object} apierror.Problem
+// @Failure 401 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /token/refresh [post]
+func (handler *UserHandler) RefreshToken(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	token, err := handler.auth.RotateRefreshToken(c.Request.Context(), input.RefreshToken)
+	if err == middlewares.ErrInvalidRefreshToken {
+		c.Error(apierror.Unauthorized("Invalid refresh token"))
+		return
+	} else if err == middlewares.ErrRefreshTokenReused {
+		c.Error(apierror.Unauthorized("Refresh token reuse detected, please log in again"))
+		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Failed to refresh token", err))
+		return
+	}
+
+	// Роль пользователя могла измениться, а аккаунт - быть отключён, поэтому токен строится по актуальным данным
+	user, err := handler.users.GetUser(c.Request.Context(), token.UserID)
+	if err != nil || user.Disabled {
+		c.Error(apierror.Unauthorized("Invalid refresh token"))
 		return
 	}
 
-	// Генерация JWT
-	token, err := middlewares.GenerateJWT(user)
+	tokens, err := handler.auth.IssueTokens(c.Request.Context(), user, token.FamilyID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.Error(apierror.Internal("Failed to generate token", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout завершает текущую сессию пользователя
+// @Summary Выход из аккаунта
+// @Description Отзывает токен доступа, которым выполнен запрос, и все токены обновления его сессии
+// @Tags Пользователи
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "status"
+// @Failure 401 {object} apierror.Problem
+// @Failure 500 {object} apierror.Problem
+// @Router /logout [post]
+func (handler *UserHandler) Logout(c *gin.Context) {
+	familyID := c.GetString("tokenFamily")
+	if familyID == "" {
+		c.Error(apierror.Unauthorized("Invalid token"))
+		return
+	}
+
+	if err := handler.auth.RevokeFamily(c.Request.Context(), familyID); err != nil {
+		c.Error(apierror.Internal("Failed to log out", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, gin.H{"status": "logged out"})
 }
 
+// Register регистрирует нового пользователя с ролью user
 // @Summary Регистрирует пользователя
-// @Description Регистрирует нового пользователя
+// @Description Регистрирует нового пользователя с ролью user и отправляет письмо подтверждения на указанный адрес почты. Пароль должен содержать от 8 до 72 символов, буквы и цифры, не содержать имя пользователя и не быть распространённым
 // @Tags Пользователи
 // @Accept json
 // @Produce json
-// @Param user body models.User true "New user data"
+// @Param user body models.Registration true "username, password и необязательный email"
 // @Success 200 {object} map[string]string "status"
-// @Failure 400 {object} map[string]string "error"
-// @Failure 500 {object} map[string]string "error"
+// @Failure 400 {object} apierror.Problem
+// @Failure 409 {object} apierror.Problem "Имя пользователя занято"
+// @Failure 429 {object} apierror.Problem "Слишком много регистраций с адреса клиента, см. заголовок Retry-After"
+// @Failure 500 {object} apierror.Problem
 // @Router /register [post]
 func (handler *UserHandler) Register(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var registration models.Registration
+	if !bindJSON(c, &registration) {
+		return
+	}
+	// Без адреса пользователь не смог бы его подтвердить и войти
+	if handler.account.RequireVerifiedEmail && registration.Email == "" {
+		c.Error(apierror.Validation("Validation failed", validation.FieldError{Field: "email", Code: "required", Message: "is required"}))
 		return
 	}
 
-	collection := handler.database.Collection("users")
-	_, err := collection.InsertOne(context.TODO(), user)
+	hash, err := bcrypt.GenerateFromPassword([]byte(registration.Password), handler.password.BcryptCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not register user"})
+		c.Error(apierror.Internal("Could not register user", err))
+		return
+	}
+
+	user := models.User{
+		Username:  registration.Username,
+		Password:  string(hash),
+		Email:     registration.Email,
+		Role:      models.RoleUser,
+		CreatedAt: time.Now(),
+	}
+	err = handler.users.CreateUser(c.Request.Context(), &user)
+	if err == databases.ErrConflict {
+		c.Error(apierror.Conflict("Username already taken"))
 		return
+	} else if err != nil {
+		c.Error(apierror.Internal("Could not register user", err))
+		return
+	}
+	if user.Email != "" {
+		handler.sendVerification(c.Request.Context(), &user)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "user registered"})
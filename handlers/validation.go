@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"myproject/apierror"
+	"myproject/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// bindJSON разбирает тело запроса в target и проверяет его по тегам binding.
+// При ошибке передаёт в gin ошибку проверки со списком неверных полей и возвращает false
+func bindJSON(c *gin.Context, target interface{}) bool {
+	if err := c.ShouldBindJSON(target); err != nil {
+		c.Error(apierror.Validation("Validation failed", validation.Errors(err)...))
+		return false
+	}
+	return true
+}
+
+// validateStruct проверяет по тегам binding структуру, собранную обработчиком, а не разобранную из тела запроса.
+// Возвращает список неверных полей, пустой при успешной проверке
+func validateStruct(target interface{}) []validation.FieldError {
+	if err := binding.Validator.ValidateStruct(target); err != nil {
+		return validation.Errors(err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"myproject/config"
+	"reflect"
+	"strings"
+)
+
+// redacted заменяет значения секретных полей в логе
+const redacted = "[REDACTED]"
+
+// sensitiveKeys - части имён полей, значения которых не должны попадать в лог
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie"}
+
+type contextKey struct{}
+
+// CreateLogger создаёт структурированный логгер с уровнем и форматом из конфигурации.
+// Значения секретных полей (пароли, токены) заменяются на [REDACTED], в том числе внутри структур и словарей
+func CreateLogger(cfg config.LogConfig, output io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("log level: %w", err)
+	}
+
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	switch cfg.Format {
+	case "json":
+		return slog.New(slog.NewJSONHandler(output, options)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(output, options)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+}
+
+// WithContext возвращает контекст, содержащий логгер logger
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext возвращает логгер запроса, сохранённый middleware, или логгер по умолчанию
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// Redact возвращает копию value, в которой значения секретных полей заменены на [REDACTED].
+// Структуры, словари и срезы приводятся к виду, в котором они записываются в JSON
+func Redact(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return value
+	}
+	return redactValue(decoded)
+}
+
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if isSensitive(attr.Key) {
+		return slog.String(attr.Key, redacted)
+	}
+	if attr.Value.Kind() != slog.KindAny {
+		return attr
+	}
+
+	value := attr.Value.Any()
+	if _, ok := value.(error); ok {
+		return attr
+	}
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice:
+		return slog.Any(attr.Key, Redact(value))
+	}
+	return attr
+}
+
+func redactValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if isSensitive(key) {
+				value[key] = redacted
+			} else {
+				value[key] = redactValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,49 +1,278 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"myproject/apierror"
+	"myproject/audit"
+	"myproject/config"
 	"myproject/databases"
-	_ "myproject/docs"
+	_ "myproject/docs/v1"
 	"myproject/handlers"
+	"myproject/logging"
+	"myproject/media"
+	"myproject/metrics"
 	"myproject/middlewares"
+	"myproject/notifications"
+	"myproject/ratelimit"
+	"myproject/tracing"
+	"myproject/trash"
+	"myproject/validation"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.mongodb.org/mongo-driver/event"
 )
 
+// Документация каждой версии API генерируется в отдельный пакет docs/<версия>
+//go:generate go run github.com/swaggo/swag/cmd/swag init --instanceName v1 --output docs/v1
+
 // @title Pet Management API
 // @version 1.0
 // @description API для подбора домашних животных
 // @host localhost:8080
-// // @BasePath /v1
+// @BasePath /v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "путь к YAML-файлу конфигурации")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("Failed to load config: ", err)
+	}
+	logger, err := logging.CreateLogger(cfg.Log, os.Stdout)
+	if err != nil {
+		log.Fatal("Failed to create logger: ", err)
+	}
+	// Записи стандартного пакета log и библиотек тоже проходят через структурированный логгер
+	slog.SetDefault(logger)
+	fatal := func(message string, err error) {
+		logger.Error(message, "error", err)
+		os.Exit(1)
+	}
+
+	// Секреты в конфигурации имеют тип config.Secret и не попадают в лог
+	logger.Info("loaded config", "config", fmt.Sprintf("%+v", *cfg))
+
+	// ctx отменяется по SIGINT или SIGTERM и останавливает сервер и фоновые задачи
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Спаны запросов и команд MongoDB отправляются экспортёром из конфигурации. По умолчанию трассировка выключена
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, os.Stdout)
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
 
-	router := gin.Default()
-	database, err := databases.Connect()
-	petHandler := handlers.CreatePetHandler(database)
-	userHandler := handlers.CreateUserHandler(database)
+	// Правила проверки тел запросов, используемые в тегах binding моделей
+	if err := validation.Register(); err != nil {
+		fatal("failed to register validators", err)
+	}
+
+	// Ошибки обработчиков и паники отдаются клиенту в формате application/problem+json
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("invalid trusted proxies", err)
+	}
+	router.Use(middlewares.RequestID(), tracing.Middleware(cfg.Tracing.ServiceName), middlewares.RequestLogger(logger), metrics.Middleware(), apierror.Handler(), apierror.Recovery())
+	router.NoRoute(func(c *gin.Context) {
+		c.Error(apierror.NotFound("Route not found"))
+	})
+
+	// Выбор хранилища: storage: memory позволяет запустить API без MongoDB
+	var stores *databases.Stores
+	var database *databases.MongoDB
+	var healthChecks []handlers.HealthCheck
+	switch cfg.Storage {
+	case "memory":
+		logger.Info("using in-memory storage")
+		stores = databases.CreateMemoryStores()
+	case "mongo":
+		database, err = databases.Connect(cfg.Mongo, []*event.CommandMonitor{tracing.MongoMonitor(), metrics.MongoMonitor()}, logger)
+		if err != nil {
+			fatal("failed to connect to database", err)
+		}
+
+		stores, err = databases.CreateMongoStores(context.TODO(), database)
+		if err != nil {
+			fatal("failed to create indexes", err)
+		}
+		healthChecks = append(healthChecks, handlers.HealthCheck{Name: "mongodb", Check: database.Ping})
+	}
 
+	if err := databases.SeedDefaultRoles(context.TODO(), stores.Roles); err != nil {
+		fatal("failed to create default roles", err)
+	}
+
+	// Фотографии животных хранятся в локальном каталоге и раздаются по адресу из конфигурации
+	blobStore, err := media.CreateLocalBlobStore(cfg.Media.Dir, cfg.Media.BaseURL)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal("failed to create media storage", err)
 	}
-	defer database.Disconnect()
+	router.Static(cfg.Media.BaseURL, blobStore.Dir())
 
-	// Публичные маршруты
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	router.POST("/login", userHandler.Login)
-	router.POST("/register", userHandler.Register)
-	router.GET("/pets", petHandler.GetPet)
-	router.GET("/pets/:id", petHandler.GetPets)
+	// Уведомления по сохранённым поискам всегда попадают во внутренний почтовый ящик,
+	// а при настроенном SMTP-сервере дублируются на почту
+	var notifier notifications.Notifier = notifications.CreateInboxNotifier(stores.Notifications)
+	if cfg.Notifications.SMTP.Host != "" {
+		notifier = notifications.MultiNotifier{notifier, notifications.CreateSMTPNotifier(cfg.Notifications.SMTP)}
+	}
+	// Служебные письма (подтверждение почты, сброс пароля) отправляются через SMTP, а без него
+	// при разработке сохраняются в каталог account.mail_dir или записываются в лог
+	var mailer notifications.Mailer
+	switch {
+	case cfg.Notifications.SMTP.Host != "":
+		mailer = notifications.CreateSMTPNotifier(cfg.Notifications.SMTP)
+	case cfg.Account.MailDir != "":
+		if mailer, err = notifications.CreateFileMailer(cfg.Account.MailDir); err != nil {
+			fatal("failed to create mail directory", err)
+		}
+	default:
+		mailer = notifications.CreateLogMailer(logger)
+	}
 
-	// Защищенные маршруты (только для админов)
-	adminRoutes := router.Group("/admin")
-	adminRoutes.Use(middlewares.Authenticate("admin"))
-	{
-		adminRoutes.POST("/pets", petHandler.CreatePet)
-		adminRoutes.PUT("/pets", petHandler.UpdatePet)
-		adminRoutes.DELETE("/pets", petHandler.DeletePet)
+	// Фоновые задачи останавливаются вместе с сервером. До закрытия соединения с базой данных
+	// дожидаемся, пока они завершат текущую операцию
+	var background sync.WaitGroup
+	runInBackground := func(task func()) {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			task()
+		}()
 	}
 
-	router.Run()
+	matcher := notifications.CreateMatcher(stores.Searches, stores.Pets, stores.Users, notifier, logger)
+	runInBackground(func() { matcher.Run(ctx) })
+	runInBackground(func() { matcher.RunDigests(ctx, cfg.Notifications.DigestInterval) })
+
+	purger := trash.CreatePurger(stores.Pets, stores.Favorites, blobStore, logger)
+	runInBackground(func() { purger.Run(ctx, cfg.Trash.PurgeInterval, cfg.Trash.Retention) })
+
+	api := createAPIHandlers(cfg, stores, matcher, blobStore, mailer)
+
+	// Все маршруты монтируются под префиксом версии. Новая версия добавляется в этот список
+	// со своей функцией регистрации, документацией docs/<версия> и, при необходимости, пометкой устаревшей
+	versions := []apiVersion{
+		{Prefix: "/v1", Docs: "v1", Register: registerV1},
+	}
+	// Маршруты без префикса остаются для старых клиентов и помечены устаревшими
+	if cfg.Server.LegacyRoutes {
+		versions = append(versions, apiVersion{
+			Register: registerV1,
+			Deprecation: &middlewares.Deprecation{
+				Since:     legacyRoutesDeprecatedSince,
+				Sunset:    cfg.Server.LegacySunset,
+				Successor: "/v1",
+			},
+		})
+	}
+	mountAPI(router, api, versions)
+
+	// Служебные маршруты для проверок оркестратора не зависят от версии API
+	health := handlers.CreateHealthHandler(healthChecks...)
+	router.GET("/healthz", health.Liveness)
+	router.GET("/readyz", health.Readiness)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	server := &http.Server{
+		Addr:         cfg.Server.Address,
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	serverErrors := make(chan error, 1)
+	go func() {
+		logger.Info("listening", "address", cfg.Server.Address)
+		serverErrors <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErrors:
+		fatal("server failed", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	// Новые соединения больше не принимаются, выполняющиеся запросы завершаются не дольше shutdown_timeout
+	logger.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("failed to finish in-flight requests", "error", err)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		logger.Warn("background tasks did not stop in time")
+	}
+
+	if database != nil {
+		if err := database.Disconnect(shutdownCtx); err != nil {
+			logger.Error("failed to disconnect from database", "error", err)
+		}
+	}
+	// Спаны последних запросов отправляются до выхода
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("failed to flush traces", "error", err)
+	}
+	logger.Info("server stopped")
+}
+
+// createAPIHandlers создаёт обработчики и middleware маршрутов API поверх хранилищ stores
+func createAPIHandlers(cfg *config.Config, stores *databases.Stores, matcher *notifications.Matcher, blobStore media.BlobStore, mailer notifications.Mailer) *apiHandlers {
+	auditLogger := audit.CreateLogger(stores.Audit)
+
+	auth := middlewares.CreateAuth(stores.Tokens, stores.Roles, cfg.JWT)
+
+	// Ограничения хранятся в памяти процесса (rate_limit.backend: memory). Для нескольких экземпляров сервиса
+	// нужна реализация ratelimit.Limiter поверх общего хранилища
+	var loginRules, registerRules, accountEmailRules []ratelimit.Rule
+	if cfg.RateLimit.Enabled {
+		loginRules = []ratelimit.Rule{
+			{Name: "login_ip", Limiter: ratelimit.CreateMemoryLimiter(cfg.RateLimit.LoginPerIP), Key: ratelimit.ByIP},
+			{Name: "login_username", Limiter: ratelimit.CreateMemoryLimiter(cfg.RateLimit.LoginPerUsername), Key: ratelimit.ByUsername},
+		}
+		registerRules = []ratelimit.Rule{
+			{Name: "register_ip", Limiter: ratelimit.CreateMemoryLimiter(cfg.RateLimit.RegisterPerIP), Key: ratelimit.ByIP},
+		}
+		// Ограничение по адресу защищает почтовый ящик от потока писем, отправляемых с разных адресов клиентов
+		accountEmailRules = []ratelimit.Rule{
+			{Name: "account_email_ip", Limiter: ratelimit.CreateMemoryLimiter(cfg.RateLimit.AccountEmailPerIP), Key: ratelimit.ByIP},
+			{Name: "account_email_address", Limiter: ratelimit.CreateMemoryLimiter(cfg.RateLimit.AccountEmailPerAddress), Key: ratelimit.ByEmail},
+		}
+	}
+	lockout := ratelimit.CreateLockout(stores.LoginAttempts, cfg.Lockout)
+
+	return &apiHandlers{
+		auth:         auth,
+		pets:         handlers.CreatePetHandler(stores.Pets, stores.Shelters, stores.Favorites, matcher, auditLogger, blobStore, cfg.Media),
+		users:        handlers.CreateUserHandler(stores.Users, stores.Roles, stores.Applications, stores.Favorites, stores.Searches, stores.Notifications, auth, lockout, mailer, auditLogger, cfg.Password, cfg.Account),
+		applications: handlers.CreateApplicationHandler(stores.Applications, stores.Pets, auditLogger),
+		shelters:     handlers.CreateShelterHandler(stores.Shelters, stores.Pets, stores.Users, auth, auditLogger),
+		roles:        handlers.CreateRoleHandler(stores.Roles, stores.Users, stores.Shelters, auth, auditLogger),
+		audit:        handlers.CreateAuditHandler(stores.Audit),
+		searches:     handlers.CreateSearchHandler(stores.Searches, stores.Notifications),
+
+		loginLimit:        ratelimit.Middleware(loginRules...),
+		registerLimit:     ratelimit.Middleware(registerRules...),
+		accountEmailLimit: ratelimit.Middleware(accountEmailRules...),
+	}
 }
@@ -0,0 +1,20 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound возвращается хранилищем файлов, если файл с указанным ключом не существует
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore - хранилище двоичных файлов (фотографий и миниатюр).
+// Ключ - относительный путь файла вида "pets/<id>/<имя>"
+type BlobStore interface {
+	Save(ctx context.Context, key string, data io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL возвращает адрес, по которому клиенты могут получить файл
+	URL(key string) string
+}
@@ -0,0 +1,96 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalBlobStore - реализация BlobStore в локальной файловой системе.
+// Файлы раздаются самим веб-сервером по префиксу baseURL
+type LocalBlobStore struct {
+	dir     string
+	baseURL string
+}
+
+func CreateLocalBlobStore(dir, baseURL string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalBlobStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Dir возвращает каталог, в котором хранятся файлы
+func (store *LocalBlobStore) Dir() string {
+	return store.dir
+}
+
+func (store *LocalBlobStore) Save(ctx context.Context, key string, data io.Reader) error {
+	filename, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы не отдавать клиентам недописанный файл
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
+
+func (store *LocalBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	filename, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return file, err
+}
+
+func (store *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	filename, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrBlobNotFound
+	}
+	return err
+}
+
+func (store *LocalBlobStore) URL(key string) string {
+	return store.baseURL + "/" + path.Clean(key)
+}
+
+// path преобразует ключ в путь к файлу, не допуская выхода за пределы каталога хранилища
+func (store *LocalBlobStore) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" {
+		return "", errors.New("empty blob key")
+	}
+	return filepath.Join(store.dir, filepath.FromSlash(cleaned)), nil
+}
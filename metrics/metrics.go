@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Результаты попытки входа для счётчика auth_logins_total
+const (
+	LoginSuccess = "success"
+	// LoginFailure - неверное имя пользователя или пароль
+	LoginFailure = "failure"
+	// LoginError - вход не выполнен из-за внутренней ошибки
+	LoginError = "error"
+	// LoginLocked - вход отклонён, так как имя пользователя заблокировано после неудачных попыток
+	LoginLocked = "locked"
+)
+
+// registry содержит только метрики приложения и среды выполнения Go, без глобальных метрик сторонних библиотек
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route, method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	httpRequestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests currently being served by route.",
+	}, []string{"route"})
+
+	mongoOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongodb_operation_duration_seconds",
+		Help:    "MongoDB command latency by collection and command.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"collection", "command"})
+
+	mongoOperationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mongodb_operation_errors_total",
+		Help: "Number of failed MongoDB commands by collection and command.",
+	}, []string{"collection", "command"})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_logins_total",
+		Help: "Number of login attempts by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		httpRequestsInFlight,
+		mongoOperationDuration,
+		mongoOperationErrors,
+		logins,
+	)
+}
+
+// Handler отдаёт метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// Middleware считает HTTP-запросы и их длительность. Маршрут записывается шаблоном (/v1/pets/:id),
+// а не фактическим путём, чтобы число значений метки не росло с числом объектов
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		inFlight := httpRequestsInFlight.WithLabelValues(route)
+		inFlight.Inc()
+		started := time.Now()
+
+		c.Next()
+
+		inFlight.Dec()
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(route, c.Request.Method, status).Inc()
+		httpRequestDuration.WithLabelValues(route, c.Request.Method, status).Observe(time.Since(started).Seconds())
+	}
+}
+
+// RecordLogin учитывает попытку входа с результатом result (LoginSuccess, LoginFailure, LoginError или LoginLocked)
+func RecordLogin(result string) {
+	logins.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MongoMonitor возвращает наблюдатель команд драйвера MongoDB, который записывает
+// длительность и ошибки операций по коллекциям. Подключается при создании клиента
+func MongoMonitor() *event.CommandMonitor {
+	// Имя коллекции есть только в начале команды, поэтому запоминаем его до её завершения
+	var started sync.Map
+
+	finished := func(command event.CommandFinishedEvent) string {
+		key := commandKey(command.ConnectionID, command.RequestID)
+		collection, ok := started.LoadAndDelete(key)
+		if !ok {
+			return "none"
+		}
+		return collection.(string)
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, command *event.CommandStartedEvent) {
+			started.Store(commandKey(command.ConnectionID, command.RequestID), commandCollection(command))
+		},
+		Succeeded: func(ctx context.Context, command *event.CommandSucceededEvent) {
+			collection := finished(command.CommandFinishedEvent)
+			mongoOperationDuration.WithLabelValues(collection, command.CommandName).Observe(command.Duration.Seconds())
+		},
+		Failed: func(ctx context.Context, command *event.CommandFailedEvent) {
+			collection := finished(command.CommandFinishedEvent)
+			mongoOperationDuration.WithLabelValues(collection, command.CommandName).Observe(command.Duration.Seconds())
+			mongoOperationErrors.WithLabelValues(collection, command.CommandName).Inc()
+		},
+	}
+}
+
+func commandKey(connectionID string, requestID int64) string {
+	return connectionID + "/" + strconv.FormatInt(requestID, 10)
+}
+
+// commandCollection возвращает коллекцию, к которой относится команда. Для команд find, insert, update
+// и других имя коллекции - значение первого поля, для getMore - поле collection
+func commandCollection(command *event.CommandStartedEvent) string {
+	if command.CommandName == "getMore" {
+		if collection, ok := command.Command.Lookup("collection").StringValueOK(); ok {
+			return collection
+		}
+		return "none"
+	}
+
+	element, err := command.Command.IndexErr(0)
+	if err != nil {
+		return "none"
+	}
+	if collection, ok := element.Value().StringValueOK(); ok {
+		return collection
+	}
+	return "none"
+}
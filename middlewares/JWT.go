@@ -1,21 +1,151 @@
 package middlewares
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"myproject/config"
+	"myproject/databases"
 	"myproject/models"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// Секрет для подписи JWT
-var jwtSecret = []byte("my_secret_key")
+var (
+	// ErrInvalidRefreshToken - токен обновления не существует или истёк
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused - предъявлен уже использованный токен обновления, всё семейство токенов отозвано
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// TokenPair - токены, выдаваемые пользователю при входе и обновлении
+type TokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn - время жизни токена доступа в секундах
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// Auth выдаёт, обновляет и проверяет токены пользователей и их права доступа
+type Auth struct {
+	tokens databases.TokenStore
+	roles  databases.RoleStore
+	config config.JWTConfig
+}
+
+func CreateAuth(tokens databases.TokenStore, roles databases.RoleStore, config config.JWTConfig) *Auth {
+	return &Auth{tokens: tokens, roles: roles, config: config}
+}
 
-// GenerateJWT - Генерация JWT для пользователя
-func GenerateJWT(user *models.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+// GenerateJWT - Генерация JWT доступа для пользователя. familyID связывает токен
+// с семейством токенов обновления, чтобы при отзыве семейства перестал действовать и он
+func (auth *Auth) GenerateJWT(user *models.User, familyID string) (string, error) {
+	claims := jwt.MapClaims{
 		"id":   user.ID.Hex(),
 		"role": user.Role,
-		"exp":  time.Now().Add(time.Hour * 24 * 7).Unix(),
+		"jti":  primitive.NewObjectID().Hex(),
+		"fam":  familyID,
+		"exp":  time.Now().Add(auth.config.AccessTTL).Unix(),
+	}
+	// Сотрудник приюта получает в токене приют, которым он управляет
+	if !user.ShelterID.IsZero() {
+		claims["shelter_id"] = user.ShelterID.Hex()
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(auth.config.Secret.Value()))
+}
+
+// IssueTokens выдаёт пользователю токен доступа и новый токен обновления.
+// Пустой familyID начинает новое семейство (новую сессию)
+func (auth *Auth) IssueTokens(ctx context.Context, user *models.User, familyID string) (*TokenPair, error) {
+	if familyID == "" {
+		familyID = primitive.NewObjectID().Hex()
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = auth.tokens.CreateRefreshToken(ctx, &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		Hash:      hashToken(refreshToken),
+		CreatedAt: now,
+		ExpiresAt: now.Add(auth.config.RefreshTTL),
 	})
-	return token.SignedString(jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := auth.GenerateJWT(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{Token: accessToken, RefreshToken: refreshToken, ExpiresIn: int64(auth.config.AccessTTL.Seconds())}, nil
+}
+
+// RotateRefreshToken проверяет токен обновления и помечает его использованным.
+// Повторное предъявление токена означает его кражу, поэтому в этом случае отзывается всё семейство
+func (auth *Auth) RotateRefreshToken(ctx context.Context, refreshToken string) (*models.RefreshToken, error) {
+	token, err := auth.tokens.GetRefreshToken(ctx, hashToken(refreshToken))
+	if err == databases.ErrNotFound {
+		return nil, ErrInvalidRefreshToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	if token.Used || token.Revoked {
+		if err := auth.RevokeFamily(ctx, token.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if token.ExpiresAt.Before(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	err = auth.tokens.MarkRefreshTokenUsed(ctx, token.ID)
+	if err == databases.ErrConflict {
+		// Токен успели использовать параллельным запросом
+		if err := auth.RevokeFamily(ctx, token.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	} else if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// RevokeFamily отзывает все токены обновления семейства и выданные с ними токены доступа
+func (auth *Auth) RevokeFamily(ctx context.Context, familyID string) error {
+	if err := auth.tokens.RevokeTokenFamily(ctx, familyID); err != nil {
+		return err
+	}
+	return auth.tokens.RevokeToken(ctx, familyID, time.Now().Add(auth.config.AccessTTL))
+}
+
+// randomToken генерирует случайный токен обновления
+func randomToken() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// hashToken возвращает хеш токена обновления, под которым он хранится в базе данных
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,120 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"myproject/databases"
+	"myproject/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidAccountToken - токен подтверждения почты или сброса пароля подделан, истёк или уже использован
+var ErrInvalidAccountToken = errors.New("invalid account token")
+
+// IssueAccountToken выдаёт одноразовый токен с назначением purpose для адреса email, действующий ttl.
+// Ранее выданные пользователю токены с тем же назначением перестают действовать.
+// Токен подписан секретом JWT, поэтому подделанный токен отклоняется без обращения к базе данных
+func (auth *Auth) IssueAccountToken(ctx context.Context, userID primitive.ObjectID, purpose, email string, ttl time.Duration) (string, error) {
+	random, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	token := random + "." + auth.signAccountToken(purpose, random)
+
+	if err := auth.tokens.DeleteAccountTokens(ctx, userID, purpose); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	err = auth.tokens.CreateAccountToken(ctx, &models.AccountToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		Hash:      hashToken(token),
+		Email:     email,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// CheckAccountToken проверяет подпись токена с назначением purpose и возвращает его, не помечая использованным.
+// Позволяет проверить данные запроса до того, как токен будет израсходован
+func (auth *Auth) CheckAccountToken(ctx context.Context, token, purpose string) (*models.AccountToken, error) {
+	if !auth.validAccountToken(token, purpose) {
+		return nil, ErrInvalidAccountToken
+	}
+
+	accountToken, err := auth.tokens.GetAccountToken(ctx, hashToken(token), purpose)
+	if err == databases.ErrNotFound {
+		return nil, ErrInvalidAccountToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	return accountToken, nil
+}
+
+// UseAccountToken проверяет подпись токена с назначением purpose и помечает его использованным
+func (auth *Auth) UseAccountToken(ctx context.Context, token, purpose string) (*models.AccountToken, error) {
+	if !auth.validAccountToken(token, purpose) {
+		return nil, ErrInvalidAccountToken
+	}
+
+	accountToken, err := auth.tokens.UseAccountToken(ctx, hashToken(token), purpose)
+	if err == databases.ErrNotFound {
+		return nil, ErrInvalidAccountToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	return accountToken, nil
+}
+
+// RevokeUserSessions отзывает все сессии пользователя: токены обновления и выданные с ними токены доступа
+func (auth *Auth) RevokeUserSessions(ctx context.Context, userID primitive.ObjectID) error {
+	familyIDs, err := auth.tokens.RevokeUserTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(auth.config.AccessTTL)
+	for _, familyID := range familyIDs {
+		if err := auth.tokens.RevokeToken(ctx, familyID, expiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeAccountTokens отменяет выданные пользователю токены с назначениями purposes
+func (auth *Auth) RevokeAccountTokens(ctx context.Context, userID primitive.ObjectID, purposes ...string) error {
+	for _, purpose := range purposes {
+		if err := auth.tokens.DeleteAccountTokens(ctx, userID, purpose); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validAccountToken проверяет подпись токена
+func (auth *Auth) validAccountToken(token, purpose string) bool {
+	random, signature, found := strings.Cut(token, ".")
+	return found && hmac.Equal([]byte(signature), []byte(auth.signAccountToken(purpose, random)))
+}
+
+// signAccountToken возвращает подпись случайной части токена. Назначение входит в подпись,
+// чтобы токен подтверждения почты нельзя было предъявить для сброса пароля
+func (auth *Auth) signAccountToken(purpose, random string) string {
+	mac := hmac.New(sha256.New, []byte(auth.config.Secret.Value()))
+	mac.Write([]byte(purpose + ":" + random))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
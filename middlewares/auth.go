@@ -1,44 +1,110 @@
 package middlewares
 
 import (
-	"net/http"
+	"errors"
+	"myproject/apierror"
+	"myproject/databases"
+	"myproject/logging"
 	"strings"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-// Authenticate для проверки JWT
-func Authenticate(requiredRole string) gin.HandlerFunc {
+// Authenticate для проверки JWT. Права доступа проверяются отдельно middleware RequirePermission
+func (auth *Auth) Authenticate() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthorized("Authorization header required"))
 			return
 		}
 
-		tokenString := strings.Split(authHeader, "Bearer ")[1]
+		tokenString, ok := strings.CutPrefix(authHeader, "Bearer ")
+		if !ok || tokenString == "" {
+			apierror.Abort(c, apierror.Unauthorized("Authorization header must use the Bearer scheme"))
+			return
+		}
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte("your_secret_key"), nil
+			// Принимаем только HMAC-подпись, иначе токен можно подделать сменой алгоритма
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, errors.New("unexpected signing method")
+			}
+			return []byte(auth.config.Secret.Value()), nil
 		})
 
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthorized("Invalid token"))
 			return
 		}
 
-		claims := token.Claims.(jwt.MapClaims)
-		role := claims["role"].(string)
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			apierror.Abort(c, apierror.Unauthorized("Invalid token"))
+			return
+		}
+		role, _ := claims["role"].(string)
 
-		if role != requiredRole {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access forbidden"})
-			c.Abort()
+		// Токен мог быть отозван сам по себе или вместе со своим семейством при выходе из аккаунта
+		tokenID, _ := claims["jti"].(string)
+		familyID, _ := claims["fam"].(string)
+		revoked, err := auth.tokens.IsTokenRevoked(c.Request.Context(), tokenID, familyID)
+		if err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to check token", err))
+			return
+		}
+		if revoked {
+			apierror.Abort(c, apierror.Unauthorized("Token revoked"))
 			return
 		}
 
 		c.Set("userID", claims["id"])
+		userID, _ := claims["id"].(string)
+		ctx := c.Request.Context()
+		c.Request = c.Request.WithContext(logging.WithContext(ctx, logging.FromContext(ctx).With("user_id", userID)))
+		c.Set("role", role)
+		c.Set("tokenFamily", familyID)
+		if shelterID, ok := claims["shelter_id"].(string); ok {
+			c.Set("shelterID", shelterID)
+		}
+		c.Next()
+	}
+}
+
+// OptionalAuthenticate пропускает запросы без заголовка Authorization, а при его наличии проверяет токен
+// так же, как Authenticate. Используется на публичных маршрутах, ответ которых зависит от пользователя
+func (auth *Auth) OptionalAuthenticate() gin.HandlerFunc {
+	authenticate := auth.Authenticate()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
+
+// RequirePermission пропускает только пользователей, роль которых обладает всеми правами permissions.
+// Права ролей читаются из хранилища при каждом запросе, поэтому их изменение применяется сразу.
+// Должен использоваться после Authenticate
+func (auth *Auth) RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, err := auth.roles.GetRole(c.Request.Context(), c.GetString("role"))
+		if err == databases.ErrNotFound {
+			apierror.Abort(c, apierror.Forbidden("Access forbidden"))
+			return
+		} else if err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to check permissions", err))
+			return
+		}
+
+		for _, permission := range permissions {
+			if !role.HasPermission(permission) {
+				apierror.Abort(c, apierror.Forbidden("Access forbidden"))
+				return
+			}
+		}
+
 		c.Next()
 	}
 }
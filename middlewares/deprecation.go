@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecation описывает устаревший маршрут или версию API
+type Deprecation struct {
+	// Since - дата, с которой маршрут считается устаревшим
+	Since time.Time
+	// Sunset - дата отключения маршрута. Если не задана, заголовок Sunset не отправляется
+	Sunset time.Time
+	// Successor - адрес замены: новый маршрут или префикс новой версии API
+	Successor string
+}
+
+// Deprecated добавляет к ответам заголовки Deprecation (RFC 9745), Sunset (RFC 8594)
+// и Link на замену устаревшего маршрута
+func Deprecated(deprecation Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", fmt.Sprintf("@%d", deprecation.Since.Unix()))
+		if !deprecation.Sunset.IsZero() {
+			c.Header("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if deprecation.Successor != "" {
+			c.Writer.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", deprecation.Successor))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"log/slog"
+	"myproject/logging"
+	"myproject/tracing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogger сохраняет в контексте запроса логгер с идентификатором запроса, чтобы все записи
+// обработчиков и хранилищ можно было связать между собой, и по завершении записывает итог запроса.
+// Если запрос трассируется, в записи попадает и trace_id. Должен использоваться после RequestID и tracing.Middleware
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		requestLogger := logger.With("request_id", c.GetString("requestID"))
+		if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+			requestLogger = requestLogger.With("trace_id", traceID)
+		}
+		c.Request = c.Request.WithContext(logging.WithContext(c.Request.Context(), requestLogger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		} else if status >= 400 {
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(started).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("response_size", c.Writer.Size()),
+		}
+		// Секретные параметры запроса (например, token) заменяются логгером на [REDACTED]
+		if query := c.Request.URL.Query(); len(query) > 0 {
+			attrs = append(attrs, slog.Any("query", query))
+		}
+
+		// Authenticate дополняет логгер запроса идентификатором пользователя
+		logging.FromContext(c.Request.Context()).LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Назначения одноразовых токенов, отправляемых пользователю по почте
+const (
+	AccountTokenVerifyEmail   = "verify_email"
+	AccountTokenResetPassword = "reset_password"
+)
+
+// AccountToken - одноразовый токен подтверждения почты или сброса пароля. Сам токен отправляется
+// пользователю в письме, а в базе данных хранится только его хеш
+type AccountToken struct {
+	ID      primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID  primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Purpose string             `json:"purpose" bson:"purpose"`
+	Hash    string             `json:"-" bson:"hash"`
+	// Email - адрес, на который отправлен токен. Подтверждается только он, даже если адрес пользователя успел измениться
+	Email     string    `json:"email" bson:"email"`
+	Used      bool      `json:"used" bson:"used"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Статусы заявки на усыновление домашнего животного
+const (
+	ApplicationSubmitted   = "submitted"
+	ApplicationUnderReview = "under_review"
+	ApplicationApproved    = "approved"
+	ApplicationRejected    = "rejected"
+	ApplicationWithdrawn   = "withdrawn"
+)
+
+// applicationTransitions - допустимые переходы между статусами заявки
+var applicationTransitions = map[string][]string{
+	ApplicationSubmitted:   {ApplicationUnderReview, ApplicationRejected, ApplicationWithdrawn},
+	ApplicationUnderReview: {ApplicationApproved, ApplicationRejected, ApplicationWithdrawn},
+}
+
+// Application - заявка пользователя на усыновление домашнего животного
+type Application struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	PetID     primitive.ObjectID `json:"pet_id" bson:"pet_id"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Message   string             `json:"message"`
+	Status    string             `json:"status"`
+	Comment   string             `json:"comment,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// IsOpen сообщает, находится ли заявка ещё на рассмотрении
+func (application *Application) IsOpen() bool {
+	return application.Status == ApplicationSubmitted || application.Status == ApplicationUnderReview
+}
+
+// CanTransitionApplication проверяет, допустим ли переход заявки из статуса from в статус to
+func CanTransitionApplication(from, to string) bool {
+	for _, allowed := range applicationTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
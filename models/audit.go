@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditEntry - запись журнала аудита об изменении данных через административные маршруты.
+// Записи только добавляются и не изменяются
+type AuditEntry struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ActorID   primitive.ObjectID `json:"actor_id" bson:"actor_id"`
+	ActorRole string             `json:"actor_role" bson:"actor_role"`
+	// Action - выполненное действие в формате <сущность>.<действие>, например pet.update
+	Action   string `json:"action"`
+	Entity   string `json:"entity"`
+	EntityID string `json:"entity_id" bson:"entity_id"`
+	// Changes - изменённые поля сущности
+	Changes   map[string]AuditChange `json:"changes"`
+	IP        string                 `json:"ip"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// AuditChange - значения поля до и после изменения в формате JSON. Пустая строка означает, что значения не было
+type AuditChange struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// MarshalJSON выводит значения поля как JSON, а не как строки с JSON
+func (change AuditChange) MarshalJSON() ([]byte, error) {
+	raw := func(value string) json.RawMessage {
+		if value == "" {
+			return json.RawMessage("null")
+		}
+		return json.RawMessage(value)
+	}
+	return json.Marshal(struct {
+		Before json.RawMessage `json:"before"`
+		After  json.RawMessage `json:"after"`
+	}{raw(change.Before), raw(change.After)})
+}
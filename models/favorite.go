@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Favorite - домашнее животное, добавленное пользователем в избранное
+type Favorite struct {
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	PetID     primitive.ObjectID `json:"pet_id" bson:"pet_id"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// LoginAttempts - неудачные попытки входа под одним именем пользователя. Запись ведётся и для несуществующих
+// пользователей, чтобы по блокировке нельзя было определить, зарегистрировано ли имя
+type LoginAttempts struct {
+	Username string `json:"username" bson:"_id"`
+	// Failures - число неудачных попыток подряд
+	Failures int `json:"failures" bson:"failures"`
+	// LockedUntil - до этого момента вход под именем запрещён даже с верным паролем
+	LockedUntil time.Time `json:"locked_until" bson:"locked_until"`
+	// ExpiresAt - момент, после которого счётчик попыток сбрасывается
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification - уведомление пользователя во внутреннем почтовом ящике приложения
+type Notification struct {
+	ID        primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID   `json:"user_id" bson:"user_id"`
+	Subject   string               `json:"subject"`
+	Body      string               `json:"body"`
+	PetIDs    []primitive.ObjectID `json:"pet_ids" bson:"pet_ids"`
+	Read      bool                 `json:"read"`
+	CreatedAt time.Time            `json:"created_at" bson:"created_at"`
+}
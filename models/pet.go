@@ -1,11 +1,109 @@
 package models
 
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Статусы домашнего животного в приюте
+const (
+	// PetIntake - животное только поступило в приют и ещё не готово к пристройству
+	PetIntake          = "intake"
+	PetAvailable       = "available"
+	PetOnHold          = "on_hold"
+	PetPendingAdoption = "pending_adoption"
+	PetAdopted         = "adopted"
+	// PetReturned - животное вернули в приют после усыновления
+	PetReturned = "returned"
+	PetDeceased = "deceased"
+)
+
+// Пол домашнего животного
+const (
+	GenderMale    = "male"
+	GenderFemale  = "female"
+	GenderUnknown = "unknown"
+)
+
+// PetGenders - допустимые значения пола домашнего животного
+var PetGenders = []string{GenderMale, GenderFemale, GenderUnknown}
+
+// PetSpecies - виды домашних животных, которых принимают приюты
+var PetSpecies = []string{"dog", "cat", "rabbit", "rodent", "bird", "reptile", "fish", "other"}
+
+// petTransitions - допустимые переходы между статусами домашнего животного
+var petTransitions = map[string][]string{
+	PetIntake:          {PetAvailable, PetOnHold, PetDeceased},
+	PetAvailable:       {PetOnHold, PetPendingAdoption, PetAdopted, PetDeceased},
+	PetOnHold:          {PetAvailable, PetDeceased},
+	PetPendingAdoption: {PetAvailable, PetOnHold, PetAdopted, PetDeceased},
+	PetAdopted:         {PetReturned},
+	PetReturned:        {PetIntake, PetAvailable, PetOnHold, PetDeceased},
+}
+
 // Pet структура для примера
 type Pet struct {
-	ID      int    `json:"id"`
-	Name    string `json:"name"`
-	Age     int    `json:"age"`
-	Gender  string `json:"gender"`
-	Species string `json:"species"`
-	Breed   string `json:"breed"`
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ShelterID primitive.ObjectID `json:"shelter_id" bson:"shelter_id"`
+	Name      string             `json:"name" binding:"required,max=100"`
+	// Age - возраст в полных годах
+	Age         int    `json:"age" binding:"min=0,max=50"`
+	Gender      string `json:"gender" binding:"omitempty,pet_gender"`
+	Species     string `json:"species" binding:"required,pet_species"`
+	Breed       string `json:"breed" binding:"max=100"`
+	Description string `json:"description" binding:"max=5000"`
+	// Status изменяется только через переходы статуса, в том числе при одобрении заявки
+	Status    string    `json:"status" binding:"omitempty,pet_status"`
+	Photos    []Photo   `json:"photos" bson:"photos,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	// DeletedAt и DeletedBy заполнены у животных в корзине. Такие животные скрыты из всех списков
+	// и удаляются окончательно по истечении срока хранения
+	DeletedAt *time.Time          `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	DeletedBy *primitive.ObjectID `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
+	// FavoriteCount и Favorited вычисляются при выдаче и не хранятся вместе с животным
+	FavoriteCount int  `json:"favorite_count" bson:"-"`
+	Favorited     bool `json:"favorited" bson:"-"`
+}
+
+// IsPetStatus проверяет, является ли status допустимым статусом домашнего животного
+func IsPetStatus(status string) bool {
+	if status == PetDeceased {
+		return true
+	}
+	_, ok := petTransitions[status]
+	return ok
+}
+
+// IsPetGender проверяет, является ли gender допустимым значением пола домашнего животного
+func IsPetGender(gender string) bool {
+	return slices.Contains(PetGenders, gender)
+}
+
+// IsPetSpecies проверяет, является ли species допустимым видом домашнего животного
+func IsPetSpecies(species string) bool {
+	return slices.Contains(PetSpecies, species)
+}
+
+// CanTransitionPet проверяет, допустим ли переход домашнего животного из статуса from в статус to
+func CanTransitionPet(from, to string) bool {
+	for _, allowed := range petTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PetStatusChange - запись истории статусов домашнего животного. Записи только добавляются и не изменяются
+type PetStatusChange struct {
+	ID    primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	PetID primitive.ObjectID `json:"pet_id" bson:"pet_id"`
+	// From пуст для записи о поступлении животного
+	From      string             `json:"from"`
+	To        string             `json:"to"`
+	Reason    string             `json:"reason"`
+	ChangedBy primitive.ObjectID `json:"changed_by" bson:"changed_by"`
+	ChangedAt time.Time          `json:"changed_at" bson:"changed_at"`
 }
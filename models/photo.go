@@ -0,0 +1,18 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Photo - фотография домашнего животного. Сами файлы лежат в хранилище файлов,
+// а в базе данных хранятся только их ключи
+type Photo struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id"`
+	Key          string             `json:"-" bson:"key"`
+	ThumbnailKey string             `json:"-" bson:"thumbnail_key"`
+	ContentType  string             `json:"content_type" bson:"content_type"`
+	Size         int64              `json:"size"`
+	Width        int                `json:"width"`
+	Height       int                `json:"height"`
+	Cover        bool               `json:"cover"`
+	URL          string             `json:"url" bson:"-"`
+	ThumbnailURL string             `json:"thumbnail_url" bson:"-"`
+}
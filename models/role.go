@@ -0,0 +1,64 @@
+package models
+
+// Права доступа. Роль пользователя определяет набор прав, которыми он обладает
+const (
+	PermPetsCreate         = "pets:create"
+	PermPetsUpdate         = "pets:update"
+	PermPetsDelete         = "pets:delete"
+	PermApplicationsReview = "applications:review"
+	PermSheltersManage     = "shelters:manage"
+	PermUsersManage        = "users:manage"
+	PermRolesManage        = "roles:manage"
+	PermAuditRead          = "audit:read"
+)
+
+// AllPermissions - все существующие права доступа
+var AllPermissions = []string{
+	PermPetsCreate,
+	PermPetsUpdate,
+	PermPetsDelete,
+	PermApplicationsReview,
+	PermSheltersManage,
+	PermUsersManage,
+	PermRolesManage,
+	PermAuditRead,
+}
+
+// Role - роль пользователя и её права доступа
+type Role struct {
+	Name        string   `json:"name" bson:"_id"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	// BuiltIn - встроенная роль, которую нельзя удалить
+	BuiltIn bool `json:"built_in" bson:"built_in"`
+}
+
+// HasPermission проверяет, обладает ли роль правом permission
+func (role *Role) HasPermission(permission string) bool {
+	for _, granted := range role.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRoles возвращает встроенные роли, создаваемые при первом запуске
+func DefaultRoles() []Role {
+	return []Role{
+		{Name: RoleUser, Description: "Пользователь, подбирающий домашнее животное", Permissions: []string{}, BuiltIn: true},
+		{Name: RoleAdmin, Description: "Супер-администратор", Permissions: append([]string{}, AllPermissions...), BuiltIn: true},
+		{
+			Name:        RoleShelterStaff,
+			Description: "Сотрудник приюта, управляющий животными своего приюта",
+			Permissions: []string{PermPetsCreate, PermPetsUpdate, PermPetsDelete},
+			BuiltIn:     true,
+		},
+		{
+			Name:        RoleModerator,
+			Description: "Модератор, редактирующий карточки животных и рассматривающий заявки",
+			Permissions: []string{PermPetsUpdate, PermApplicationsReview},
+			BuiltIn:     true,
+		},
+	}
+}
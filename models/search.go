@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Режимы уведомления о новых животных, подходящих под сохранённый поиск
+const (
+	// SearchModeImmediate - уведомление отправляется сразу после появления подходящего животного
+	SearchModeImmediate = "immediate"
+	// SearchModeDaily - подходящие животные собираются в ежедневную сводку
+	SearchModeDaily = "daily"
+)
+
+// SavedSearch - сохранённый пользователем набор фильтров списка домашних животных
+type SavedSearch struct {
+	ID     primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Name   string             `json:"name"`
+	// Query - параметры фильтрации в формате строки запроса GET /pets, например species=dog&age_max=3
+	Query     string    `json:"query"`
+	Mode      string    `json:"mode"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// IsSearchMode проверяет, является ли mode допустимым режимом уведомления
+func IsSearchMode(mode string) bool {
+	return mode == SearchModeImmediate || mode == SearchModeDaily
+}
+
+// SearchMatch - домашнее животное, подошедшее под сохранённый поиск. Каждое животное
+// записывается для поиска один раз, поэтому повторные изменения не приводят к повторным уведомлениям
+type SearchMatch struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	SearchID  primitive.ObjectID `json:"search_id" bson:"search_id"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	PetID     primitive.ObjectID `json:"pet_id" bson:"pet_id"`
+	Notified  bool               `json:"notified"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
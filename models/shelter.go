@@ -0,0 +1,14 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Shelter - приют или организация, размещающая домашних животных
+type Shelter struct {
+	ID      primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name    string             `json:"name" binding:"required,max=200"`
+	Address string             `json:"address" binding:"max=500"`
+	Phone   string             `json:"phone" binding:"omitempty,phone"`
+	Email   string             `json:"email" binding:"omitempty,email,max=254"`
+	Website string             `json:"website" binding:"omitempty,http_url,max=500"`
+	Hours   string             `json:"hours" binding:"max=200"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken - токен обновления, хранящийся на сервере. Сам токен клиенту выдаётся
+// один раз, а в базе данных хранится только его хеш. Все токены, полученные друг из друга
+// последовательным обновлением, образуют семейство с общим FamilyID
+type RefreshToken struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	FamilyID  string             `json:"family_id" bson:"family_id"`
+	Hash      string             `json:"-" bson:"hash"`
+	Used      bool               `json:"used"`
+	Revoked   bool               `json:"revoked"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at"`
+}
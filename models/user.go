@@ -1,10 +1,72 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Встроенные роли пользователей. Права каждой роли хранятся в базе данных и могут быть изменены
+const (
+	RoleUser = "user"
+	// RoleAdmin - супер-администратор с доступом ко всем приютам
+	RoleAdmin = "admin"
+	// RoleShelterStaff - сотрудник приюта, управляющий только животными своего приюта
+	RoleShelterStaff = "shelter_staff"
+	// RoleModerator - модератор карточек животных и заявок
+	RoleModerator = "moderator"
+)
 
 type User struct {
-	ID       primitive.ObjectID `json:"_id,omitempty"`
+	ID       primitive.ObjectID `json:"_id,omitempty" bson:"_id,omitempty"`
 	Username string             `json:"username"`
-	Password string             `json:"password"`
-	Role     string             `json:"role"`
+	// Password - хеш пароля bcrypt. Никогда не отдаётся в ответах API
+	Password string `json:"-" bson:"password"`
+	// Email - адрес для отправки уведомлений по почте, необязателен
+	Email string `json:"email,omitempty" bson:"email,omitempty"`
+	// EmailVerified - пользователь подтвердил, что Email принадлежит ему, перейдя по ссылке из письма
+	EmailVerified bool               `json:"email_verified" bson:"email_verified"`
+	Role          string             `json:"role"`
+	ShelterID     primitive.ObjectID `json:"shelter_id,omitempty" bson:"shelter_id,omitempty"`
+	Profile       UserProfile        `json:"profile" bson:"profile"`
+	// Disabled - аккаунт отключён администратором или удалён: вход и обновление токенов запрещены
+	Disabled bool `json:"disabled" bson:"disabled"`
+	// DeletedAt - время удаления аккаунта пользователем. Удалённый аккаунт обезличивается, но не удаляется из базы,
+	// чтобы на него продолжали ссылаться заявки и журнал аудита
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+}
+
+// Варианты жилищных условий в профиле пользователя
+const (
+	HousingApartment     = "apartment"
+	HousingHouse         = "house"
+	HousingHouseWithYard = "house_with_yard"
+	HousingOther         = "other"
+)
+
+// HousingTypes - все допустимые варианты жилищных условий
+var HousingTypes = []string{HousingApartment, HousingHouse, HousingHouseWithYard, HousingOther}
+
+// IsHousing проверяет, является ли строка допустимым вариантом жилищных условий
+func IsHousing(housing string) bool {
+	return slices.Contains(HousingTypes, housing)
+}
+
+// UserProfile - сведения о пользователе, которые сотрудники приюта видят при рассмотрении его заявок.
+// Все поля необязательны
+type UserProfile struct {
+	DisplayName string `json:"display_name,omitempty" bson:"display_name,omitempty" binding:"max=100"`
+	Phone       string `json:"phone,omitempty" bson:"phone,omitempty" binding:"omitempty,phone"`
+	Housing     string `json:"housing,omitempty" bson:"housing,omitempty" binding:"omitempty,housing"`
+	// PetExperience - опыт содержания домашних животных в свободной форме
+	PetExperience string `json:"pet_experience,omitempty" bson:"pet_experience,omitempty" binding:"max=2000"`
+}
+
+// Registration - данные нового пользователя. Роль при регистрации всегда user, остальные роли назначает администратор
+type Registration struct {
+	Username string `json:"username" binding:"required,username"`
+	Password string `json:"password" binding:"required,password"`
+	Email    string `json:"email,omitempty" binding:"omitempty,email,max=254"`
 }
@@ -0,0 +1,22 @@
+package notifications
+
+import (
+	"context"
+	"myproject/databases"
+	"myproject/models"
+)
+
+// InboxNotifier сохраняет уведомления во внутренний почтовый ящик пользователя
+type InboxNotifier struct {
+	notifications databases.NotificationStore
+}
+
+func CreateInboxNotifier(notifications databases.NotificationStore) *InboxNotifier {
+	return &InboxNotifier{notifications: notifications}
+}
+
+func (notifier *InboxNotifier) Notify(ctx context.Context, user *models.User, notification *models.Notification) error {
+	inboxNotification := *notification
+	inboxNotification.UserID = user.ID
+	return notifier.notifications.CreateNotification(ctx, &inboxNotification)
+}
@@ -0,0 +1,67 @@
+package notifications
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Mailer отправляет служебные письма (подтверждение почты, сброс пароля) на произвольный адрес.
+// SMTPNotifier реализует Mailer для рабочего окружения
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// LogMailer записывает письма в лог вместо отправки. Используется при разработке, когда SMTP не настроен
+type LogMailer struct {
+	logger *slog.Logger
+}
+
+func CreateLogMailer(logger *slog.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+func (mailer *LogMailer) Send(to, subject, body string) error {
+	mailer.logger.Info("mail not sent: smtp is not configured", "to", to, "subject", subject, "body", body)
+	return nil
+}
+
+// FileMailer сохраняет каждое письмо в отдельный файл .eml в каталоге dir вместо отправки.
+// Используется при разработке: файлы открываются почтовым клиентом
+type FileMailer struct {
+	dir string
+}
+
+// CreateFileMailer создаёт каталог dir, если его ещё нет
+func CreateFileMailer(dir string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir}, nil
+}
+
+func (mailer *FileMailer) Send(to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient address %q", to)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(suffix) + ".eml"
+
+	var message strings.Builder
+	message.WriteString("To: " + to + "\n")
+	message.WriteString("Subject: " + subject + "\n")
+	message.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\n\n")
+	message.WriteString(body + "\n")
+
+	return os.WriteFile(filepath.Join(mailer.dir, name), []byte(message.String()), 0o600)
+}
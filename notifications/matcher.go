@@ -0,0 +1,236 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"myproject/databases"
+	"myproject/models"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// matcherQueueSize - число изменённых животных, ожидающих проверки
+const matcherQueueSize = 256
+
+// Matcher в фоне проверяет добавленных и изменённых животных по сохранённым поискам пользователей,
+// записывает совпадения и отправляет уведомления: сразу или в периодической сводке, в зависимости от режима поиска
+type Matcher struct {
+	searches databases.SearchStore
+	pets     databases.PetStore
+	users    databases.UserStore
+	notifier Notifier
+	logger   *slog.Logger
+	queue    chan primitive.ObjectID
+}
+
+func CreateMatcher(searches databases.SearchStore, pets databases.PetStore, users databases.UserStore, notifier Notifier, logger *slog.Logger) *Matcher {
+	return &Matcher{
+		searches: searches,
+		pets:     pets,
+		users:    users,
+		notifier: notifier,
+		logger:   logger,
+		queue:    make(chan primitive.ObjectID, matcherQueueSize),
+	}
+}
+
+// PetChanged ставит животное в очередь проверки. Не блокирует обработку запроса:
+// если очередь переполнена, проверка пропускается
+func (matcher *Matcher) PetChanged(petID primitive.ObjectID) {
+	select {
+	case matcher.queue <- petID:
+	default:
+		matcher.logger.Warn("saved search queue is full, skipping pet", "pet_id", petID.Hex())
+	}
+}
+
+// Run проверяет животных из очереди, пока не будет отменён ctx
+func (matcher *Matcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case petID := <-matcher.queue:
+			if err := matcher.MatchPet(ctx, petID); err != nil {
+				matcher.logger.Error("failed to match pet against saved searches", "pet_id", petID.Hex(), "error", err)
+			}
+		}
+	}
+}
+
+// RunDigests отправляет сводки с периодом interval, пока не будет отменён ctx
+func (matcher *Matcher) RunDigests(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := matcher.SendDigests(ctx); err != nil {
+				matcher.logger.Error("failed to send saved search digests", "error", err)
+			}
+		}
+	}
+}
+
+// MatchPet проверяет текущее состояние животного по всем сохранённым поискам.
+// Животное записывается для каждого поиска только один раз. Статус животного проверяется фильтром поиска,
+// поэтому по умолчанию находятся только животные, доступные для усыновления
+func (matcher *Matcher) MatchPet(ctx context.Context, petID primitive.ObjectID) error {
+	pet, err := matcher.pets.GetPet(ctx, petID)
+	if err == databases.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	searches, err := matcher.searches.FindSavedSearches(ctx, primitive.NilObjectID)
+	if err != nil {
+		return err
+	}
+
+	for _, search := range searches {
+		filter, err := searchFilter(&search)
+		if err != nil {
+			matcher.logger.Warn("skipping saved search with invalid query", "search_id", search.ID.Hex(), "error", err)
+			continue
+		}
+		if !filter.Matches(pet) {
+			continue
+		}
+
+		match := models.SearchMatch{SearchID: search.ID, UserID: search.UserID, PetID: pet.ID, CreatedAt: time.Now()}
+		err = matcher.searches.AddSearchMatch(ctx, &match)
+		if err == databases.ErrConflict {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if search.Mode != models.SearchModeImmediate {
+			continue
+		}
+
+		// При ошибке уведомления совпадение остаётся неотправленным и попадёт в ближайшую сводку
+		notification := &models.Notification{
+			Subject:   fmt.Sprintf("Новое животное по поиску «%s»", search.Name),
+			Body:      fmt.Sprintf("По вашему поиску «%s» найдено животное:\n%s", search.Name, describePet(pet)),
+			PetIDs:    []primitive.ObjectID{pet.ID},
+			CreatedAt: time.Now(),
+		}
+		if err := matcher.notify(ctx, search.UserID, notification); err != nil {
+			matcher.logger.Error("failed to notify user about pet", "user_id", search.UserID.Hex(), "pet_id", pet.ID.Hex(), "error", err)
+			continue
+		}
+		if err := matcher.searches.MarkMatchesNotified(ctx, []primitive.ObjectID{match.ID}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SendDigests отправляет каждому пользователю одну сводку со всеми совпадениями, о которых он ещё не уведомлён
+func (matcher *Matcher) SendDigests(ctx context.Context) error {
+	matches, err := matcher.searches.FindPendingMatches(ctx)
+	if err != nil {
+		return err
+	}
+
+	var userIDs []primitive.ObjectID
+	byUser := map[primitive.ObjectID][]models.SearchMatch{}
+	for _, match := range matches {
+		if _, ok := byUser[match.UserID]; !ok {
+			userIDs = append(userIDs, match.UserID)
+		}
+		byUser[match.UserID] = append(byUser[match.UserID], match)
+	}
+
+	allPetIDs := make([]primitive.ObjectID, len(matches))
+	for i, match := range matches {
+		allPetIDs[i] = match.PetID
+	}
+	pets, err := matcher.pets.GetPets(ctx, allPetIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		var matchIDs, petIDs []primitive.ObjectID
+		var lines []string
+		for _, match := range byUser[userID] {
+			matchIDs = append(matchIDs, match.ID)
+
+			// Животное могло быть удалено
+			pet, ok := pets[match.PetID]
+			if !ok {
+				continue
+			}
+			// Животное могло быть усыновлено или уже попасть в сводку по другому поиску
+			if pet.Status == models.PetAdopted || pet.Status == models.PetDeceased || slices.Contains(petIDs, pet.ID) {
+				continue
+			}
+			petIDs = append(petIDs, pet.ID)
+			lines = append(lines, describePet(&pet))
+		}
+
+		if len(petIDs) > 0 {
+			notification := &models.Notification{
+				Subject:   fmt.Sprintf("Новые животные по вашим поискам: %d", len(petIDs)),
+				Body:      "По вашим сохранённым поискам найдены животные:\n" + strings.Join(lines, "\n"),
+				PetIDs:    petIDs,
+				CreatedAt: time.Now(),
+			}
+			if err := matcher.notify(ctx, userID, notification); err != nil {
+				matcher.logger.Error("failed to send digest", "user_id", userID.Hex(), "error", err)
+				continue
+			}
+		}
+
+		if err := matcher.searches.MarkMatchesNotified(ctx, matchIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notify - вспомогательная функция, загружающая пользователя и отправляющая ему уведомление
+func (matcher *Matcher) notify(ctx context.Context, userID primitive.ObjectID, notification *models.Notification) error {
+	user, err := matcher.users.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	notification.UserID = user.ID
+	return matcher.notifier.Notify(ctx, user, notification)
+}
+
+// searchFilter - вспомогательная функция, строящая фильтр по параметрам сохранённого поиска
+func searchFilter(search *models.SavedSearch) (databases.PetFilter, error) {
+	values, err := url.ParseQuery(search.Query)
+	if err != nil {
+		return databases.PetFilter{}, err
+	}
+	return databases.ParsePetFilter(values)
+}
+
+// describePet - вспомогательная функция, возвращающая строку с кратким описанием животного для уведомления
+func describePet(pet *models.Pet) string {
+	details := []string{}
+	for _, detail := range []string{pet.Species, pet.Breed} {
+		if detail != "" {
+			details = append(details, detail)
+		}
+	}
+	details = append(details, fmt.Sprintf("возраст %d", pet.Age))
+
+	return fmt.Sprintf("- %s (%s), id %s", pet.Name, strings.Join(details, ", "), pet.ID.Hex())
+}
@@ -0,0 +1,26 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"myproject/models"
+)
+
+// Notifier доставляет уведомление пользователю
+type Notifier interface {
+	Notify(ctx context.Context, user *models.User, notification *models.Notification) error
+}
+
+// MultiNotifier доставляет уведомление через каждый из способов доставки.
+// Ошибка одного способа не мешает остальным, все ошибки возвращаются вместе
+type MultiNotifier []Notifier
+
+func (notifiers MultiNotifier) Notify(ctx context.Context, user *models.User, notification *models.Notification) error {
+	var errs []error
+	for _, notifier := range notifiers {
+		if err := notifier.Notify(ctx, user, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,56 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"myproject/config"
+	"myproject/models"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// SMTPNotifier отправляет уведомления на почтовый адрес пользователя.
+// Пользователи без адреса пропускаются
+type SMTPNotifier struct {
+	config config.SMTPConfig
+}
+
+func CreateSMTPNotifier(config config.SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{config: config}
+}
+
+func (notifier *SMTPNotifier) Notify(ctx context.Context, user *models.User, notification *models.Notification) error {
+	if user.Email == "" {
+		return nil
+	}
+
+	return notifier.Send(user.Email, notification.Subject, notification.Body)
+}
+
+// Send отправляет письмо с текстом body на адрес to
+func (notifier *SMTPNotifier) Send(to, subject, body string) error {
+	// Переводы строк в заголовках позволили бы подставить в письмо произвольные заголовки
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient address %q", to)
+	}
+
+	var message strings.Builder
+	message.WriteString("From: " + notifier.config.From + "\r\n")
+	message.WriteString("To: " + to + "\r\n")
+	message.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if notifier.config.Username != "" {
+		auth = smtp.PlainAuth("", notifier.config.Username, notifier.config.Password.Value(), notifier.config.Host)
+	}
+
+	address := net.JoinHostPort(notifier.config.Host, strconv.Itoa(notifier.config.Port))
+	return smtp.SendMail(address, auth, notifier.config.From, []string{to}, []byte(message.String()))
+}
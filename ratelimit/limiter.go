@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"myproject/config"
+	"sync"
+	"time"
+)
+
+// Limiter ограничивает частоту запросов по ключу (адресу клиента, имени пользователя).
+// Реализация в памяти подходит для одного экземпляра сервиса, для нескольких экземпляров
+// нужна реализация поверх общего хранилища
+type Limiter interface {
+	// Allow расходует одну попытку по ключу key. Если попыток не осталось, возвращает false
+	// и время, через которое появится следующая
+	Allow(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+// bucket - корзина токенов одного ключа
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryLimiter - потокобезопасная реализация Limiter в оперативной памяти по алгоритму корзины токенов:
+// в корзине помещается rate.Requests токенов, каждый запрос расходует один, и корзина равномерно
+// наполняется заново за rate.Period
+type MemoryLimiter struct {
+	mutex   sync.Mutex
+	rate    config.Rate
+	buckets map[string]*bucket
+	// cleaned - время последнего удаления полных корзин
+	cleaned time.Time
+}
+
+func CreateMemoryLimiter(rate config.Rate) *MemoryLimiter {
+	return &MemoryLimiter{rate: rate, buckets: map[string]*bucket{}, cleaned: time.Now()}
+}
+
+func (limiter *MemoryLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := time.Now()
+	limiter.removeFull(now)
+
+	capacity := float64(limiter.rate.Requests)
+	// perToken - время восстановления одного токена
+	perToken := limiter.rate.Period / time.Duration(limiter.rate.Requests)
+
+	current, ok := limiter.buckets[key]
+	if !ok {
+		current = &bucket{tokens: capacity, updated: now}
+		limiter.buckets[key] = current
+	}
+	current.tokens = min(capacity, current.tokens+float64(now.Sub(current.updated))/float64(perToken))
+	current.updated = now
+
+	if current.tokens < 1 {
+		return false, time.Duration((1 - current.tokens) * float64(perToken)), nil
+	}
+	current.tokens--
+	return true, 0, nil
+}
+
+// removeFull удаляет корзины, которые успели наполниться: они не отличаются от отсутствующих.
+// Проверка выполняется не чаще раза в rate.Period. Вызывается под блокировкой
+func (limiter *MemoryLimiter) removeFull(now time.Time) {
+	if now.Sub(limiter.cleaned) < limiter.rate.Period {
+		return
+	}
+	limiter.cleaned = now
+	for key, current := range limiter.buckets {
+		if now.Sub(current.updated) >= limiter.rate.Period {
+			delete(limiter.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"myproject/config"
+	"myproject/databases"
+	"time"
+)
+
+// Lockout временно запрещает вход под именем пользователя после config.Threshold неудачных попыток подряд.
+// Каждая следующая неудачная попытка удваивает блокировку, но не больше config.MaxDuration.
+// Счётчики хранятся в базе данных, поэтому блокировка общая для всех экземпляров сервиса
+type Lockout struct {
+	attempts databases.LoginAttemptStore
+	config   config.LockoutConfig
+}
+
+func CreateLockout(attempts databases.LoginAttemptStore, cfg config.LockoutConfig) *Lockout {
+	return &Lockout{attempts: attempts, config: cfg}
+}
+
+// Check возвращает, сколько ещё заблокирован вход под именем username. Ноль - вход разрешён
+func (lockout *Lockout) Check(ctx context.Context, username string) (time.Duration, error) {
+	if lockout.config.Threshold == 0 {
+		return 0, nil
+	}
+
+	attempts, err := lockout.attempts.GetLoginAttempts(ctx, username)
+	if err == databases.ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return max(time.Until(attempts.LockedUntil), 0), nil
+}
+
+// Failure учитывает неудачную попытку входа и возвращает длительность блокировки, если попытка к ней привела
+func (lockout *Lockout) Failure(ctx context.Context, username string) (time.Duration, error) {
+	if lockout.config.Threshold == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	attempts, err := lockout.attempts.RecordLoginFailure(ctx, username, now.Add(lockout.config.ResetAfter))
+	if err != nil {
+		return 0, err
+	}
+	if attempts.Failures < lockout.config.Threshold {
+		return 0, nil
+	}
+
+	duration := lockout.duration(attempts.Failures - lockout.config.Threshold)
+	if err := lockout.attempts.LockLogin(ctx, username, now.Add(duration)); err != nil {
+		return 0, err
+	}
+	return duration, nil
+}
+
+// Success сбрасывает счётчик неудачных попыток после успешного входа
+func (lockout *Lockout) Success(ctx context.Context, username string) error {
+	if lockout.config.Threshold == 0 {
+		return nil
+	}
+	return lockout.attempts.ResetLoginAttempts(ctx, username)
+}
+
+// duration возвращает длительность блокировки после excess неудачных попыток сверх порога
+func (lockout *Lockout) duration(excess int) time.Duration {
+	duration := lockout.config.Duration
+	for i := 0; i < excess && duration < lockout.config.MaxDuration; i++ {
+		duration *= 2
+	}
+	return min(duration, lockout.config.MaxDuration)
+}
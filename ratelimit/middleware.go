@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"myproject/apierror"
+	"myproject/logging"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxKeyBodySize - сколько байт тела запроса читается для поиска поля ключа
+const maxKeyBodySize = 64 << 10
+
+// KeyFunc возвращает ключ, по которому ограничивается запрос. Пустой ключ - запрос не ограничивается
+type KeyFunc func(c *gin.Context) string
+
+// Rule - ограничение запросов ограничителем Limiter по ключу Key
+type Rule struct {
+	Name    string
+	Limiter Limiter
+	Key     KeyFunc
+}
+
+// ByIP - ключ по адресу клиента. Адрес из X-Forwarded-For учитывается только для доверенных прокси
+func ByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// ByUsername - ключ по полю username JSON-тела запроса
+var ByUsername = ByBodyField("username")
+
+// ByEmail - ключ по полю email JSON-тела запроса. Адрес приводится к нижнему регистру без пробелов по краям,
+// чтобы варианты написания одного адреса расходовали общий лимит
+func ByEmail(c *gin.Context) string {
+	return strings.ToLower(strings.TrimSpace(ByBodyField("email")(c)))
+}
+
+// ByBodyField возвращает ключ по строковому полю field JSON-тела запроса. Тело восстанавливается для обработчика
+func ByBodyField(field string) KeyFunc {
+	return func(c *gin.Context) string {
+		if c.Request.Body == nil {
+			return ""
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxKeyBodySize))
+		if err != nil {
+			return ""
+		}
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+		var input map[string]interface{}
+		if json.Unmarshal(body, &input) != nil {
+			return ""
+		}
+		value, _ := input[field].(string)
+		return value
+	}
+}
+
+// Middleware отклоняет запрос с ответом 429 и заголовком Retry-After, если исчерпано хотя бы одно из ограничений rules.
+// Ошибка ограничителя не должна делать вход недоступным, поэтому такой запрос пропускается и ошибка пишется в лог
+func Middleware(rules ...Rule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, rule := range rules {
+			key := rule.Key(c)
+			if key == "" {
+				continue
+			}
+
+			allowed, retryAfter, err := rule.Limiter.Allow(c.Request.Context(), rule.Name+":"+key)
+			if err != nil {
+				logging.FromContext(c.Request.Context()).Error("rate limiter failed", "rule", rule.Name, "error", err)
+				continue
+			}
+			if !allowed {
+				apierror.Abort(c, apierror.TooManyRequests("Too many requests, try again later", retryAfter))
+				return
+			}
+		}
+		c.Next()
+	}
+}
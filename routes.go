@@ -0,0 +1,159 @@
+package main
+
+import (
+	"myproject/handlers"
+	"myproject/middlewares"
+	"myproject/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// legacyRoutesDeprecatedSince - дата перехода API на маршруты с префиксом версии
+var legacyRoutesDeprecatedSince = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+// apiHandlers - обработчики и middleware, общие для всех версий API
+type apiHandlers struct {
+	auth         *middlewares.Auth
+	pets         *handlers.PetHandler
+	users        *handlers.UserHandler
+	applications *handlers.ApplicationHandler
+	shelters     *handlers.ShelterHandler
+	roles        *handlers.RoleHandler
+	audit        *handlers.AuditHandler
+	searches     *handlers.SearchHandler
+	// loginLimit и registerLimit ограничивают частоту попыток входа и регистрации,
+	// accountEmailLimit - запросов писем подтверждения почты и сброса пароля
+	loginLimit        gin.HandlerFunc
+	registerLimit     gin.HandlerFunc
+	accountEmailLimit gin.HandlerFunc
+}
+
+// apiVersion - версия API, маршруты которой монтируются под префиксом Prefix
+type apiVersion struct {
+	Prefix string
+	// Docs - имя экземпляра документации swag (флаг --instanceName). Пусто, если у версии нет своей документации
+	Docs string
+	// Deprecation задаётся для устаревшей версии: все её ответы получают заголовки Deprecation и Sunset
+	Deprecation *middlewares.Deprecation
+	// Register регистрирует маршруты версии. Новая версия использует те же обработчики
+	// и функции регистрации групп маршрутов, заменяя только изменившиеся группы
+	Register func(routes *gin.RouterGroup, api *apiHandlers)
+}
+
+// mountAPI монтирует все версии API и их документацию
+func mountAPI(router *gin.Engine, api *apiHandlers, versions []apiVersion) {
+	for _, version := range versions {
+		routes := router.Group(version.Prefix)
+		if version.Deprecation != nil {
+			routes.Use(middlewares.Deprecated(*version.Deprecation))
+		}
+		version.Register(routes, api)
+
+		if version.Docs != "" {
+			router.GET("/swagger"+version.Prefix+"/*any", ginSwagger.WrapHandler(swaggerFiles.NewHandler(), ginSwagger.InstanceName(version.Docs)))
+		}
+	}
+}
+
+// registerV1 регистрирует маршруты первой версии API
+func registerV1(routes *gin.RouterGroup, api *apiHandlers) {
+	registerAccountRoutes(routes, api)
+	registerCatalogRoutes(routes, api)
+	registerUserRoutes(routes, api)
+	registerAdminRoutes(routes.Group("/admin", api.auth.Authenticate()), api)
+}
+
+// registerAccountRoutes - вход, регистрация, управление сессией, подтверждение почты и восстановление пароля
+func registerAccountRoutes(routes *gin.RouterGroup, api *apiHandlers) {
+	routes.POST("/login", api.loginLimit, api.users.Login)
+	routes.POST("/register", api.registerLimit, api.users.Register)
+	routes.POST("/token/refresh", api.users.RefreshToken)
+	routes.POST("/logout", api.auth.Authenticate(), api.users.Logout)
+	routes.POST("/email/verification", api.accountEmailLimit, api.users.RequestEmailVerification)
+	routes.POST("/email/verification/confirm", api.users.ConfirmEmailVerification)
+	routes.POST("/password/reset", api.accountEmailLimit, api.users.RequestPasswordReset)
+	routes.POST("/password/reset/confirm", api.users.ConfirmPasswordReset)
+}
+
+// registerCatalogRoutes - публичный каталог животных и приютов
+func registerCatalogRoutes(routes *gin.RouterGroup, api *apiHandlers) {
+	routes.GET("/pets", api.auth.OptionalAuthenticate(), api.pets.GetPets)
+	routes.GET("/pets/:id", api.auth.OptionalAuthenticate(), api.pets.GetPet)
+	routes.GET("/shelters", api.shelters.GetShelters)
+	routes.GET("/shelters/:id", api.shelters.GetShelter)
+}
+
+// registerUserRoutes - маршруты для аутентифицированных пользователей
+func registerUserRoutes(routes *gin.RouterGroup, api *apiHandlers) {
+	applicationRoutes := routes.Group("/applications", api.auth.Authenticate())
+	applicationRoutes.POST("", api.applications.SubmitApplication)
+	applicationRoutes.GET("", api.applications.GetMyApplications)
+	applicationRoutes.POST("/:id/withdraw", api.applications.WithdrawApplication)
+
+	favoriteRoutes := routes.Group("/favorites", api.auth.Authenticate())
+	favoriteRoutes.GET("", api.pets.GetFavorites)
+	favoriteRoutes.PUT("/:id", api.pets.AddFavorite)
+	favoriteRoutes.DELETE("/:id", api.pets.RemoveFavorite)
+
+	searchRoutes := routes.Group("/searches", api.auth.Authenticate())
+	searchRoutes.POST("", api.searches.CreateSearch)
+	searchRoutes.GET("", api.searches.GetSearches)
+	searchRoutes.DELETE("/:id", api.searches.DeleteSearch)
+
+	notificationRoutes := routes.Group("/notifications", api.auth.Authenticate())
+	notificationRoutes.GET("", api.searches.GetNotifications)
+	notificationRoutes.POST("/:id/read", api.searches.ReadNotification)
+
+	profileRoutes := routes.Group("/me", api.auth.Authenticate())
+	profileRoutes.GET("", api.users.GetMe)
+	profileRoutes.PATCH("", api.users.UpdateMe)
+	profileRoutes.DELETE("", api.users.DeleteMe)
+	profileRoutes.PUT("/password", api.users.ChangePassword)
+}
+
+// registerAdminRoutes - административные маршруты, доступ к которым определяется правами роли пользователя.
+// Группа routes должна требовать аутентификации
+func registerAdminRoutes(routes *gin.RouterGroup, api *apiHandlers) {
+	auth := api.auth
+
+	routes.POST("/pets", auth.RequirePermission(models.PermPetsCreate), api.pets.CreatePet)
+	routes.PUT("/pets/:id", auth.RequirePermission(models.PermPetsUpdate), api.pets.UpdatePet)
+	routes.DELETE("/pets/:id", auth.RequirePermission(models.PermPetsDelete), api.pets.DeletePet)
+	routes.GET("/pets/trash", auth.RequirePermission(models.PermPetsDelete), api.pets.GetTrash)
+	routes.POST("/pets/:id/restore", auth.RequirePermission(models.PermPetsDelete), api.pets.RestorePet)
+	routes.POST("/pets/:id/status", auth.RequirePermission(models.PermPetsUpdate), api.pets.ChangePetStatus)
+	routes.GET("/pets/:id/history", auth.RequirePermission(models.PermPetsUpdate), api.pets.GetPetStatusHistory)
+	routes.POST("/pets/:id/photos", auth.RequirePermission(models.PermPetsUpdate), api.pets.UploadPhoto)
+	routes.PUT("/pets/:id/photos/order", auth.RequirePermission(models.PermPetsUpdate), api.pets.ReorderPhotos)
+	routes.PUT("/pets/:id/photos/:photoId/cover", auth.RequirePermission(models.PermPetsUpdate), api.pets.SetCoverPhoto)
+	routes.DELETE("/pets/:id/photos/:photoId", auth.RequirePermission(models.PermPetsUpdate), api.pets.DeletePhoto)
+
+	reviewRoutes := routes.Group("/applications", auth.RequirePermission(models.PermApplicationsReview))
+	reviewRoutes.GET("", api.applications.GetApplications)
+	reviewRoutes.GET("/:id", api.applications.GetApplication)
+	reviewRoutes.PUT("/:id/status", api.applications.ReviewApplication)
+
+	shelterRoutes := routes.Group("/shelters", auth.RequirePermission(models.PermSheltersManage))
+	shelterRoutes.POST("", api.shelters.CreateShelter)
+	shelterRoutes.PUT("/:id", api.shelters.UpdateShelter)
+	shelterRoutes.DELETE("/:id", api.shelters.DeleteShelter)
+	shelterRoutes.POST("/:id/staff", auth.RequirePermission(models.PermUsersManage), api.shelters.AddStaff)
+	shelterRoutes.DELETE("/:id/staff/:username", auth.RequirePermission(models.PermUsersManage), api.shelters.RemoveStaff)
+
+	roleRoutes := routes.Group("", auth.RequirePermission(models.PermRolesManage))
+	roleRoutes.GET("/roles", api.roles.GetRoles)
+	roleRoutes.GET("/permissions", api.roles.GetPermissions)
+	roleRoutes.PUT("/roles/:name", api.roles.SaveRole)
+	roleRoutes.DELETE("/roles/:name", api.roles.DeleteRole)
+
+	userRoutes := routes.Group("/users", auth.RequirePermission(models.PermUsersManage))
+	userRoutes.GET("", api.users.GetUsers)
+	userRoutes.PUT("/:username/role", api.roles.AssignRole)
+	userRoutes.POST("/:username/disable", api.users.DisableUser)
+	userRoutes.POST("/:username/enable", api.users.EnableUser)
+
+	routes.GET("/audit", auth.RequirePermission(models.PermAuditRead), api.audit.GetAudit)
+}
@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"myproject/config"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Экспортёры трассировок, задаваемые в tracing.exporter
+const (
+	// ExporterNone - трассировки не записываются и никуда не отправляются
+	ExporterNone = "none"
+	// ExporterStdout - трассировки выводятся в output в формате JSON, удобно при локальной отладке
+	ExporterStdout = "stdout"
+	// ExporterOTLP - трассировки отправляются коллектору по протоколу OTLP/HTTP
+	ExporterOTLP = "otlp"
+)
+
+// Setup настраивает глобальный TracerProvider и распространение контекста по заголовкам W3C traceparent и baggage.
+// Возвращаемая функция отправляет накопленные спаны и останавливает экспортёр, её нужно вызвать при остановке сервиса.
+// С экспортёром none спаны не создаются, но заголовок traceparent входящего запроса передаётся дальше
+func Setup(ctx context.Context, cfg config.TracingConfig, output io.Writer) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(output))
+	case ExporterOTLP:
+		// Без адреса в конфигурации экспортёр использует OTEL_EXPORTER_OTLP_ENDPOINT или localhost:4318
+		var options []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s exporter: %w", cfg.Exporter, err)
+	}
+
+	serviceResource, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(serviceResource),
+		// Решение о записи принимает вызывающий сервис, если он передал traceparent
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Middleware создаёт спан для каждого HTTP-запроса, продолжая трассировку из заголовка traceparent.
+// Спан называется шаблоном маршрута и сохраняется в контексте запроса, поэтому обработчики должны передавать
+// в хранилища c.Request.Context(). Служебные маршруты проверок и метрик не трассируются
+func Middleware(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName, otelgin.WithFilter(func(request *http.Request) bool {
+		switch request.URL.Path {
+		case "/healthz", "/readyz", "/metrics":
+			return false
+		}
+		return true
+	}))
+}
+
+// MongoMonitor возвращает наблюдатель команд драйвера MongoDB, который создаёт дочерний спан для каждой команды
+// с именем коллекции и операции. Текст команды в спан не записывается, так как может содержать личные данные
+func MongoMonitor() *event.CommandMonitor {
+	return otelmongo.NewMonitor()
+}
+
+// TraceID возвращает идентификатор трассировки, к которой относится ctx, или пустую строку
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
@@ -0,0 +1,63 @@
+package trash
+
+import (
+	"context"
+	"log/slog"
+	"myproject/databases"
+	"myproject/media"
+	"time"
+)
+
+// Purger окончательно удаляет домашних животных, пролежавших в корзине дольше срока хранения,
+// вместе с их фотографиями и записями в избранном
+type Purger struct {
+	pets      databases.PetStore
+	favorites databases.FavoriteStore
+	blobs     media.BlobStore
+	logger    *slog.Logger
+}
+
+func CreatePurger(pets databases.PetStore, favorites databases.FavoriteStore, blobs media.BlobStore, logger *slog.Logger) *Purger {
+	return &Purger{pets: pets, favorites: favorites, blobs: blobs, logger: logger}
+}
+
+// Purge удаляет животных, перемещённых в корзину раньше чем retention назад, и возвращает их число.
+// Ошибки удаления файлов и избранного только записываются в лог: сами животные уже удалены
+func (purger *Purger) Purge(ctx context.Context, retention time.Duration) (int, error) {
+	pets, err := purger.pets.PurgeDeletedPets(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, pet := range pets {
+		for _, photo := range pet.Photos {
+			purger.blobs.Delete(ctx, photo.Key)
+			purger.blobs.Delete(ctx, photo.ThumbnailKey)
+		}
+		if err := purger.favorites.DeleteFavoritesForPet(ctx, pet.ID); err != nil {
+			purger.logger.Error("failed to delete favorites of purged pet", "pet_id", pet.ID.Hex(), "error", err)
+		}
+	}
+
+	return len(pets), nil
+}
+
+// Run очищает корзину с периодом interval, пока не будет отменён ctx
+func (purger *Purger) Run(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := purger.Purge(ctx, retention)
+			if err != nil {
+				purger.logger.Error("failed to purge deleted pets", "error", err)
+			} else if count > 0 {
+				purger.logger.Info("purged deleted pets", "count", count)
+			}
+		}
+	}
+}
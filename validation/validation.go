@@ -0,0 +1,252 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"myproject/models"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// commonPasswords - распространённые пароли, которые формально проходят правило password,
+// но при подборе проверяются первыми
+var commonPasswords = []string{
+	"password1", "password12", "password123", "passw0rd", "p4ssw0rd", "qwerty123", "qwerty12", "qwertyui1",
+	"1q2w3e4r", "1q2w3e4r5t", "1qaz2wsx", "zaq12wsx", "abc12345", "abcd1234", "a1b2c3d4", "iloveyou1",
+	"welcome1", "welcome123", "letmein1", "admin123", "administrator1", "monkey123", "dragon123", "football1",
+	"baseball1", "sunshine1", "princess1", "trustno1", "12345678a", "123456789a", "123qweasd", "qwe123qwe",
+}
+
+var (
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{3,32}$`)
+	phonePattern    = regexp.MustCompile(`^\+?[0-9 ()-]{5,20}$`)
+)
+
+// FieldError - ошибка проверки одного поля тела запроса
+type FieldError struct {
+	// Field - путь к полю в JSON, например name или photo_ids[1]. Пуст, если тело запроса не удалось разобрать
+	Field string `json:"field,omitempty"`
+	// Code - машиночитаемый код ошибки: имя нарушенного правила (required, max, pet_species, ...),
+	// type при неверном типе значения или malformed при неверном JSON
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Register регистрирует в валидаторе gin правила проверки, используемые в тегах binding моделей,
+// и включает вывод имён полей в том виде, в котором они записаны в JSON
+func Register() error {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unsupported validator engine")
+	}
+
+	validate.RegisterTagNameFunc(jsonFieldName)
+
+	rules := map[string]func(string) bool{
+		"pet_gender":  models.IsPetGender,
+		"pet_species": models.IsPetSpecies,
+		"pet_status":  models.IsPetStatus,
+		"search_mode": models.IsSearchMode,
+		"permission":  isPermission,
+		"objectid":    primitive.IsValidObjectID,
+		"username":    usernamePattern.MatchString,
+		"password":    isStrongPassword,
+		"phone":       phonePattern.MatchString,
+		"housing":     models.IsHousing,
+	}
+	validate.RegisterStructValidation(validateRegistration, models.Registration{})
+	for tag, rule := range rules {
+		rule := rule
+		err := validate.RegisterValidation(tag, func(field validator.FieldLevel) bool {
+			return rule(field.Field().String())
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Errors преобразует ошибку разбора или проверки тела запроса в список ошибок по полям
+func Errors(err error) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make([]FieldError, len(validationErrors))
+		for i, fieldError := range validationErrors {
+			fields[i] = FieldError{
+				Field:   fieldPath(fieldError.Namespace()),
+				Code:    fieldError.Tag(),
+				Message: message(fieldError),
+			}
+		}
+		return fields
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		return []FieldError{{Field: typeError.Field, Code: "type", Message: "must be " + typeName(typeError.Type)}}
+	}
+
+	return []FieldError{{Code: "malformed", Message: "Request body must be a valid JSON object"}}
+}
+
+// jsonFieldName возвращает имя поля структуры в JSON
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// fieldPath отбрасывает из пути поля имя проверяемой структуры
+func fieldPath(namespace string) string {
+	_, path, found := strings.Cut(namespace, ".")
+	if !found {
+		return namespace
+	}
+	return path
+}
+
+// message возвращает понятное описание нарушенного правила
+func message(fieldError validator.FieldError) string {
+	param := fieldError.Param()
+	switch fieldError.Tag() {
+	case "min", "max":
+		bound := "at least"
+		if fieldError.Tag() == "max" {
+			bound = "at most"
+		}
+		switch fieldError.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("must be %s %s characters long", bound, param)
+		case reflect.Slice, reflect.Map:
+			return fmt.Sprintf("must contain %s %s items", bound, param)
+		default:
+			return fmt.Sprintf("must be %s %s", bound, param)
+		}
+	}
+	return ruleMessage(fieldError.Tag(), param)
+}
+
+// ruleMessage возвращает описание нарушенного правила tag с параметром param, не зависящее от типа поля
+func ruleMessage(tag, param string) string {
+	switch tag {
+	case "required":
+		return "is required"
+	case "eq":
+		return "must be " + param
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "email":
+		return "must be a valid email address"
+	case "http_url":
+		return "must be a valid http or https URL"
+	case "pet_gender":
+		return "must be one of: " + strings.Join(models.PetGenders, ", ")
+	case "pet_species":
+		return "must be one of: " + strings.Join(models.PetSpecies, ", ")
+	case "pet_status":
+		return "must be a valid pet status"
+	case "search_mode":
+		return "must be one of: " + models.SearchModeImmediate + ", " + models.SearchModeDaily
+	case "permission":
+		return "must be a known permission"
+	case "objectid":
+		return "must be a valid ID"
+	case "username":
+		return "must be 3 to 32 characters long and contain only letters, digits, dots, underscores and hyphens"
+	case "password":
+		return "must be 8 to 72 characters long and contain at least one letter and one digit"
+	case "phone":
+		return "must be a valid phone number"
+	case "housing":
+		return "must be one of: " + strings.Join(models.HousingTypes, ", ")
+	case "password_username":
+		return "must not contain the username"
+	case "password_common":
+		return "is too common, choose a less predictable password"
+	}
+	return "is invalid"
+}
+
+// typeName возвращает название ожидаемого типа JSON-значения
+func typeName(kind reflect.Type) string {
+	switch kind.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
+
+func isPermission(permission string) bool {
+	return slices.Contains(models.AllPermissions, permission)
+}
+
+// isStrongPassword проверяет длину пароля и наличие в нём букв и цифр.
+// Верхняя граница длины - ограничение bcrypt в 72 байта
+func isStrongPassword(password string) bool {
+	if len(password) < 8 || len(password) > 72 {
+		return false
+	}
+	return strings.IndexFunc(password, unicode.IsLetter) >= 0 && strings.IndexFunc(password, unicode.IsDigit) >= 0
+}
+
+// CheckPassword проверяет новый пароль пользователя username по правилу password и правилам, которым нужно
+// имя пользователя или словарь. Возвращает false и ошибку поля password, если пароль не подходит
+func CheckPassword(username, password string) (FieldError, bool) {
+	if !isStrongPassword(password) {
+		return FieldError{Field: "password", Code: "password", Message: ruleMessage("password", "")}, false
+	}
+	if tag := passwordRule(username, password); tag != "" {
+		return FieldError{Field: "password", Code: tag, Message: ruleMessage(tag, "")}, false
+	}
+	return FieldError{}, true
+}
+
+// validateRegistration дополняет правило password проверками, которым нужны другие поля или словарь
+func validateRegistration(level validator.StructLevel) {
+	registration := level.Current().Interface().(models.Registration)
+	// Пароль, не прошедший правило password, уже получил ошибку
+	if !isStrongPassword(registration.Password) {
+		return
+	}
+
+	if tag := passwordRule(registration.Username, registration.Password); tag != "" {
+		level.ReportError(registration.Password, "password", "Password", tag, "")
+	}
+}
+
+// passwordRule возвращает имя нарушенного правила, если пароль содержит имя пользователя
+// или входит в список распространённых паролей, иначе - пустую строку
+func passwordRule(username, password string) string {
+	password = strings.ToLower(password)
+	if username != "" && strings.Contains(password, strings.ToLower(username)) {
+		return "password_username"
+	}
+	if slices.Contains(commonPasswords, password) {
+		return "password_common"
+	}
+	return ""
+}